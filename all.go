@@ -15,11 +15,14 @@ import (
 	"github.com/fatih/color"
 	"github.com/gosuri/uilive"
 	"github.com/kamackay/all/browser"
+	"github.com/kamackay/all/clean"
+	"github.com/kamackay/all/config"
 	"github.com/kamackay/all/files"
 	"github.com/kamackay/all/l"
 	"github.com/kamackay/all/model"
 	"github.com/kamackay/all/utils"
 	"github.com/kamackay/all/version"
+	"github.com/kamackay/all/video"
 	"github.com/kamackay/godash/parallel"
 )
 
@@ -59,12 +62,171 @@ func printPath(file *model.FileBean, opts model.Opts) {
 	}
 }
 
+// transcode re-encodes the videos scoring over the threshold, the ones that could recover the most go first
+func transcode(scores []*model.VideoScore, cfg *config.Config, opts model.Opts) {
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+	preset := opts.Preset
+	if preset == "" {
+		preset = cfg.Video.Preset
+	}
+	if preset == "" {
+		preset = video.DefaultPreset
+	}
+	args, err := video.PresetArgs(preset, cfg.Video.Presets)
+	if err != nil {
+		red.Printf("%+v\n", err)
+		return
+	}
+	queue := make([]*model.VideoScore, 0)
+	for _, s := range scores {
+		if s.Score > opts.Threshold && s.CouldRecover > 0 {
+			queue = append(queue, s)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].CouldRecover > queue[j].CouldRecover
+	})
+	var couldRecover int64
+	jobs := make([]video.TranscodeJob, 0, len(queue))
+	for _, s := range queue {
+		couldRecover += s.CouldRecover
+		jobs = append(jobs, video.TranscodeJob{Path: s.Name, Size: s.Size, Duration: s.Duration})
+	}
+	fmt.Printf("%d videos to transcode with %s (%s), could recover %s\n", len(jobs), preset,
+		strings.Join(args, " "), utils.HumanizeBytes(uint64(couldRecover)))
+	if opts.DryRun {
+		for _, s := range queue {
+			fmt.Printf("Would transcode %s (score %0.2f, could recover %s)\n", s.Name, s.Score,
+				utils.HumanizeBytes(uint64(s.CouldRecover)))
+		}
+		return
+	}
+	if len(jobs) == 0 || !opts.Yes && !utils.AskForConfirmation(fmt.Sprintf("Transcode %d videos?", len(jobs))) {
+		return
+	}
+	var saved uint64
+	replaced := 0
+	video.TranscodeAll(jobs, args, opts.Jobs, video.FFProbe{}, func(r video.TranscodeResult) {
+		switch {
+		case r.Err != nil:
+			red.Printf("Could not transcode %s: %+v\n", r.Job.Path, r.Err)
+		case !r.Replaced:
+			fmt.Printf("Kept %s, transcoding it came out at %s instead of %s\n", r.Job.Path,
+				utils.HumanizeBytes(r.NewSize), utils.HumanizeBytes(r.Job.Size))
+		default:
+			saved += r.Job.Size - r.NewSize
+			replaced++
+			green.Printf("Transcoded %s: %s -> %s\n", r.Job.Path, utils.HumanizeBytes(r.Job.Size),
+				utils.HumanizeBytes(r.NewSize))
+		}
+	})
+	fmt.Printf("Replaced %d of %d videos, saved %s\n", replaced, len(jobs), utils.HumanizeBytes(saved))
+}
+
+// rmEmpty deletes empty files and folders under base, folders that only hold other empty folders and junk files count as empty
+func rmEmpty(base string, opts model.Opts) {
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+	cfg, err := config.Load()
+	if err != nil {
+		red.Printf("Could not read config: %+v\n", err)
+	}
+	emptyOpts := clean.EmptyOptions{
+		Files:     opts.RmEmpty.Files,
+		Dirs:      opts.RmEmpty.Dirs,
+		Junk:      cfg.Clean.Junk,
+		KeepEmpty: cfg.Clean.KeepEmpty,
+	}
+	if len(emptyOpts.Junk) == 0 {
+		emptyOpts.Junk = clean.DefaultJunk
+	}
+	if len(emptyOpts.KeepEmpty) == 0 {
+		emptyOpts.KeepEmpty = clean.DefaultKeepEmpty
+	}
+	plan := clean.Empty(base, emptyOpts)
+	describe := func(dirs, files, junk int) string {
+		parts := make([]string, 0)
+		if opts.RmEmpty.Dirs {
+			parts = append(parts, fmt.Sprintf("%d empty directories", dirs))
+		}
+		if opts.RmEmpty.Files {
+			parts = append(parts, fmt.Sprintf("%d empty files", files))
+		}
+		if junk > 0 {
+			parts = append(parts, fmt.Sprintf("%d junk files", junk))
+		}
+		return strings.Join(parts, ", ")
+	}
+	summary := describe(plan.Dirs, plan.Files, plan.Junk)
+	if opts.DryRun || opts.Verbose {
+		for _, step := range plan.Steps {
+			fmt.Printf("Would delete %s %s\n", stepKind(step), step.Path)
+		}
+	}
+	if opts.DryRun {
+		fmt.Printf("Would delete %s\n", summary)
+		return
+	}
+	if len(plan.Steps) == 0 {
+		fmt.Printf("Nothing to delete\n")
+		return
+	}
+	if !opts.Yes && !utils.AskForConfirmation(fmt.Sprintf("Delete %s?", summary)) {
+		return
+	}
+	var dirs, files, junk, failed int
+	for _, step := range plan.Steps {
+		if err := os.Remove(step.Path); err != nil {
+			red.Printf("Could not delete %s: %+v\n", step.Path, err)
+			failed++
+			continue
+		}
+		switch {
+		case step.Dir:
+			dirs++
+		case step.Junk:
+			junk++
+		default:
+			files++
+		}
+		green.Printf("Deleted %s %s\n", stepKind(step), step.Path)
+	}
+	fmt.Printf("Deleted %s", describe(dirs, files, junk))
+	if failed > 0 {
+		red.Printf(", %d could not be deleted", failed)
+	}
+	fmt.Println()
+}
+
+func stepKind(step clean.Step) string {
+	switch {
+	case step.Dir:
+		return "empty directory"
+	case step.Junk:
+		return "junk file"
+	default:
+		return "empty file"
+	}
+}
+
 func main() {
 	red := color.New(color.FgRed)
 	yellow := color.New(color.FgYellow)
 	green := color.New(color.FgGreen)
+	if len(os.Args) > 1 && os.Args[1] == cleanCommand {
+		// A folder named clean is still listed with `all ./clean` or `all -- clean`
+		runClean(os.Args[2:])
+		return
+	}
 	var opts model.Opts
-	ctx := kong.Parse(&opts)
+	ctx := kong.Parse(&opts, kong.Description(fmt.Sprintf(
+		"List the files under Directory with their sizes. `all %s --rules rules.yaml [directory]` cleans up with rules, see `all %s --help`",
+		cleanCommand, cleanCommand)))
+	if opts.Output != "text" {
+		// Keep stdout parseable
+		opts.Quiet = true
+	}
 
 	start := time.Now()
 
@@ -90,6 +252,21 @@ func main() {
 		return
 	}
 
+	if opts.Browser && opts.Compare != "" {
+		left, err := filepath.Abs(opts.Compare)
+		if err != nil {
+			fmt.Printf("%+v\n", err)
+			return
+		}
+		c, err := browser.NewCompare(left, base)
+		if err != nil {
+			fmt.Printf("%+v\n", err)
+			return
+		}
+		c.Run()
+		return
+	}
+
 	if opts.Browser {
 		// Run Browser
 		l.Print("Running Browser!")
@@ -143,6 +320,12 @@ func main() {
 		return
 	}
 
+	if opts.RmEmpty.Enabled() {
+		// Works from the folder structure itself, bottom up, rather than the file list
+		rmEmpty(base, opts)
+		return
+	}
+
 	cache := make(files.FileCache)
 
 	var fileList []*model.FileBean
@@ -183,66 +366,97 @@ func main() {
 		ctx.Exit(0)
 	}()
 
-	if opts.RmEmpty {
-		for _, f := range utils.FlipSlice(utils.Unique(fileList, func(file *model.FileBean) string { return file.Name })) {
-			if f.IsDir() {
-				empty, err := utils.IsEmpty(f.Name)
-				if err != nil {
-					red.Printf("Could not read %s: %+v\n", f.Name, err)
-					continue
-				}
-				if empty {
-					if opts.Verbose {
-						fmt.Printf("Deleting empty directory %s\n", f.Name)
-					}
-					if opts.Yes || utils.AskForConfirmation(fmt.Sprintf("Delete empty directory %s?", f.Name)) {
-						err := os.Remove(f.Name)
-						if err != nil {
-							red.Printf("Could not delete %s: %+v\n", f.Name, err)
-						} else {
-							green.Printf("Deleted %s\n", f.Name)
-						}
-					}
-				}
-			}
-		}
+	if opts.Media {
+		listMedia(fileList, opts)
 		return
 	}
 
 	if opts.VideoScore {
+		probes, err := video.LoadCache(video.FFProbe{})
+		if err != nil {
+			l.Error(err)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			red.Printf("Could not read config: %+v\n", err)
+		}
+		targets := video.NewTargets(cfg.Video.Targets)
+		// Returns nil for anything that isn't a video ffprobe can read, those are left out of the list
 		scoreFunc := func(bean *model.FileBean) *model.VideoScore {
-			couldRecover := utils.GetPotentialBytesToCompress(bean)
-			score, err := utils.GetVideoScore(bean)
+			if bean.IsDir() || files.DetectMedia(bean.Name) != files.Video {
+				return nil
+			}
+			// Each file is probed once, score and recoverable bytes come from the same result
+			probe, err := probes.Probe(bean.Name, bean.Size, bean.LastModified())
 			if err != nil {
-				return model.NewScore(1000, bean, couldRecover)
+				l.Error(err)
+				return nil
 			}
-			return model.NewScore(score, bean, couldRecover)
+			result, err := video.Score(probe, bean.Size, targets)
+			if err != nil {
+				l.Error(err)
+				return nil
+			}
+			s := model.NewScore(result.Score, bean, result.CouldRecover)
+			s.Codec = result.Codec
+			s.Width = result.Width
+			s.Height = result.Height
+			s.Duration = result.Duration
+			s.BitRate = result.BitRate
+			return s
 		}
-		scores, err := parallel.Map(fileList, runtime.NumCPU(), func(f *model.FileBean) *model.VideoScore {
+		results, err := parallel.Map(fileList, runtime.NumCPU(), func(f *model.FileBean) *model.VideoScore {
 			return scoreFunc(f)
 		})
+		if err := probes.Save(); err != nil {
+			l.Error(err)
+		}
 		if err != nil {
 			red.Printf("Error in processing files: %+v\n", err)
 			return
 		}
-		sort.Slice(scores, func(i, j int) bool {
-			return scores[i].CouldRecover < scores[j].CouldRecover
-		})
-		for _, s := range scores {
-			var message string
-			score := s.Score
-			if opts.Verbose {
-				message = fmt.Sprintf("%0.2f (%s)\t\t- %s (could recover: %s)\n", score, utils.HumanizeBytes(s.Size), s.Name, utils.HumanizeBytes(uint64(s.CouldRecover)))
-			} else {
-				message = fmt.Sprintf("%0.2f\t\t- %s\n", score, s.Name)
+		scores := make([]*model.VideoScore, 0, len(results))
+		for _, s := range results {
+			if s != nil {
+				scores = append(scores, s)
 			}
-			if score > 20 {
-				red.Printf(message)
-			} else if score > 15 {
-				yellow.Printf(message)
-			} else if score > 0 {
-				fmt.Printf(message)
+		}
+		sortScores(scores, opts.Sort, opts.Reverse)
+		switch opts.Output {
+		case "json":
+			err = writeScoresJSON(os.Stdout, scores)
+		case "csv":
+			err = writeScoresCSV(os.Stdout, scores)
+		default:
+			// Red is over the transcode threshold, yellow over halfway there from a perfect score
+			warning := (video.TheoreticalBestScore + opts.Threshold) / 2
+			for _, s := range scores {
+				var message string
+				score := s.Score
+				if opts.Verbose {
+					message = fmt.Sprintf("%0.2f (%s, %s %dx%d %s)\t\t- %s (could recover: %s)\n", score,
+						utils.HumanizeBytes(s.Size), s.Codec, s.Width, s.Height, formatBitRate(s.BitRate), s.Name,
+						utils.HumanizeBytes(uint64(s.CouldRecover)))
+				} else {
+					message = fmt.Sprintf("%0.2f\t\t- %s\n", score, s.Name)
+				}
+				if score > opts.Threshold {
+					red.Printf(message)
+				} else if score > warning {
+					yellow.Printf(message)
+				} else if score > 0 {
+					fmt.Printf(message)
+				}
 			}
+			size, couldRecover := scoreTotals(scores)
+			fmt.Printf("%d videos, %s, could recover %s\n", len(scores), utils.HumanizeBytes(size),
+				utils.HumanizeBytes(couldRecover))
+		}
+		if err != nil {
+			red.Printf("Could not write report: %+v\n", err)
+		}
+		if opts.Transcode {
+			transcode(scores, cfg, opts)
 		}
 		return
 	}
@@ -1,180 +1,159 @@
 package browser
 
 import (
+	"context"
 	"fmt"
-	"github.com/dustin/go-humanize"
-	"github.com/kamackay/all/files"
+	"github.com/fsnotify/fsnotify"
+	"github.com/kamackay/all/config"
 	"github.com/kamackay/all/l"
 	"github.com/kamackay/all/model"
 	"github.com/kamackay/all/utils"
+	"github.com/mattn/go-runewidth"
 	"github.com/nsf/termbox-go"
-	"github.com/skratchdot/open-golang/open"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
 const (
 	green = termbox.ColorGreen
 	black = termbox.ColorBlack
+
+	// Number of lines moved per mouse wheel tick
+	wheelStep = 3
 )
 
 type Browser struct {
+	view
+	cursor
 	path              string
-	Width, Height     int
-	SelectedLine      int
 	Files             []File
-	fileLoadMutex     sync.Mutex
 	loading           *model.LoadingInfo
-	file              *model.FileMode
-	pollChan          chan *termbox.Event
+	preview           *Preview
+	loadID            uint64
+	cancelLoad        context.CancelFunc
 	sort              model.SortType
+	reverse           bool
 	confirmations     []model.Confirmation
 	timeReport        string
 	autoUpdateEnabled bool
 	updatedString     string
 	reloadInterval    time.Duration
+	prompt            *prompt
+	history           []string
+	historyIndex      int
+	bookmarks         map[string]string
+	positions         map[string]position
+	filesPath         string
+	watcher           *fsnotify.Watcher
+	watchSubtree      bool
+	pendingChanges    map[string]bool
+	debounce          <-chan time.Time
+	clipboard         *clipboard
+	operation         *model.Operation
+	openWith          map[string]string
+	diskUsage         string
 }
 
-func (b *Browser) getFiles(render bool) {
-	path := b.path
-	go func() {
-		start := time.Now()
-		defer func() {
-			defer b.update()
-			now := time.Now()
-			diff := now.Sub(start)
-			if diff < time.Millisecond * 200 {
-				// Updating this folder is pretty quick, update it more frequently
-				b.reloadInterval = time.Second
-			} else {
-				b.reloadInterval = time.Second * 5
-			}
-			if diff > time.Second {
-				b.timeReport = fmt.Sprintf("Done in %s", humanize.RelTime(start, now, "", ""))
-			} else {
-				b.timeReport = fmt.Sprintf("Done in %dms", diff.Milliseconds())
-			}
-			b.updatedString = time.Now().Format("2006-01-02 15:04:05")
-		}()
-		b.fileLoadMutex.Lock()
-		defer b.fileLoadMutex.Unlock()
-		defer b.update()
-		b.file = nil
-		info, _ := os.Stat(path)
-		if info != nil && !info.IsDir() {
-			start, err := files.ReadStart(path, b.Height*b.Width)
-			if err != nil {
-				start = fmt.Sprintf("Error Reading file: %+v", err)
-			}
-			b.file = &model.FileMode{Contents: start}
-			b.Files = []File{makeRelativeFile(path, "..")}
-			return
-		}
-		l.Print(fmt.Sprintf("Pulling files for %s", path))
-		fs := files.GetFiles(path)
-		fileList := make([]File, len(fs))
-		l.Print(fmt.Sprintf("Pulled %d files for %s", len(fs), path))
-		b.loading = &model.LoadingInfo{
-			Item:   0,
-			Total:  len(fs),
-			Render: render,
-		}
-		for x, f := range fs {
-			b.loading.Current = f.Name()
-			b.update()
-			filename := filepath.Join(path, f.Name())
-			fileList[x] = File{
-				Path:         filename,
-				Size:         files.GetSize(path, f),
-				LastModified: files.PrintTime(f),
-				Dir:          f.IsDir(),
-				Children:     files.CountChildren(filename),
-			}
-			b.loading.Item = x
-			b.update()
-		}
-		sort.Slice(fileList, func(i, j int) bool {
-			switch b.sort {
-			case model.SortName:
-				return strings.Compare(strings.ToLower(fileList[i].Path), strings.ToLower(fileList[j].Path)) < 0
-			case model.SortSize:
-				return fileList[i].Size > fileList[j].Size
-			default:
-				return true // Shouldn't be possible, so whatever
-			}
-		})
-		fileList = append([]File{
-			makeRelativeFile(path, ".."),
-		}, fileList...)
-		b.loading = nil
-		b.Files = fileList
-	}()
+func New(root string) (*Browser, error) {
+	return NewWithScreen(root, termboxScreen{})
 }
 
-func New(root string) (*Browser, error) {
-	err := termbox.Init()
+// NewWithScreen creates a browser that draws to the given screen instead of the terminal
+func NewWithScreen(root string, screen Screen) (*Browser, error) {
+	v, err := newView(screen)
 	if err != nil {
 		return nil, err
 	}
-	w, h := termbox.Size()
+	var preview *Preview
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		// Show the file's folder with the file open in the preview pane
+		preview = NewPreview(root)
+		root = filepath.Dir(root)
+	}
 	b := &Browser{
+		view:              v,
 		path:              root,
-		Width:             w,
-		Height:            h,
-		SelectedLine:      0,
-		pollChan:          make(chan *termbox.Event),
+		preview:           preview,
 		sort:              model.SortSize,
 		confirmations:     make([]model.Confirmation, 0),
 		autoUpdateEnabled: false,
 		reloadInterval:    time.Second * 5,
+		history:           []string{root},
+		positions:         make(map[string]position),
+		pendingChanges:    make(map[string]bool),
 	}
+	bookmarks, err := config.LoadBookmarks()
+	b.reportError(err)
+	b.bookmarks = bookmarks
+	b.openWith = normalizeExtensions(b.configure().OpenWith)
 	b.getFiles(true)
-	b.setSize(h, w)
 	return b, nil
 }
 
 func (b *Browser) Run() {
 	defer b.close()
 	go b.poll()
-	for {
+	for !b.quitting {
 		b.Render()
 		select {
 		case <-time.After(b.reloadInterval):
 			if b.loading != nil {
 				break
 			}
-			if !b.autoUpdateEnabled {
+			if !b.autoUpdateEnabled || b.watcher != nil {
+				// Watched folders are refreshed as soon as they change
 				break
 			}
 			b.getFiles(false)
-			break
-		case e := <-b.pollChan:
-			if e == nil {
-				continue
-			} else if e.Ch == 'q' || e.Key == termbox.KeyCtrlC {
-				return
-			} else {
-				b.keyPress(*e)
-			}
-			break
+		case <-b.debounce:
+			b.debounce = nil
+			b.flushChanges()
+		case msg := <-b.messages:
+			b.handle(msg)
 		}
 	}
 }
 
-func (b *Browser) drawString(line string, y int, fg, bg termbox.Attribute) {
-	for x := 0; x < len(line); x++ {
-		termbox.SetCell(x, y, rune(line[x]), fg, bg)
+// drawText draws the line rune by rune starting at column x, clipping anything past maxWidth cells.
+// Returns the number of cells used
+func drawText(screen Screen, line string, x, y, maxWidth int, fg, bg termbox.Attribute) int {
+	used := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := tabWidth - used%tabWidth
+			for i := 0; i < spaces && used < maxWidth; i++ {
+				screen.SetCell(x+used, y, ' ', fg, bg)
+				used++
+			}
+			continue
+		}
+		r = displayRune(r)
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			// Combining characters are attached to the previous cell by the terminal
+			continue
+		}
+		if used+w > maxWidth {
+			break
+		}
+		screen.SetCell(x+used, y, r, fg, bg)
+		used += w
 	}
+	return used
 }
 
 func (b *Browser) Render() {
-	l.Error(termbox.Clear(termbox.ColorWhite, termbox.ColorDefault))
-	defer termbox.Flush()
-	height := b.Height - 1
+	l.Error(b.screen.Clear(termbox.ColorWhite, termbox.ColorDefault))
+	b.screen.HideCursor()
+	defer b.screen.Flush()
+	defer b.renderStatus()
+	if b.prompt != nil {
+		defer b.renderPrompt()
+	} else if b.operation != nil {
+		defer b.renderOperation()
+	}
 	if loading := b.loading; loading != nil && loading.Render {
 		text := fmt.Sprintf("Loading... %d of %d, currently: %s", loading.Item, loading.Total, loading.Current)
 		b.drawString(text, 8, green, black)
@@ -184,145 +163,222 @@ func (b *Browser) Render() {
 		b.drawString(confirmation.Message, 8, green, black)
 		b.drawString("Press y to confirm, n to dismiss", 9, green, black)
 		return
-	}
-	if b.file != nil {
-		lines := strings.Split(b.file.Contents, "\n")
-		for x, line := range lines {
-			b.drawString(line, x+2, green, black)
-		}
+	} else if b.showHelp {
+		b.renderHelp()
 		return
 	}
 	line := 1
-	b.drawString(fmt.Sprintf("Current: %s (Sorting by %s) [Auto Update: %s] (%s) {Updated: %s}", b.path,
+	b.drawString(fmt.Sprintf("Current: %s (Sorting by %s%s) [Auto Update: %s] (%s) {Updated: %s}", b.path,
 		model.SortTypeName(b.sort),
+		b.getReverseString(),
 		b.getAutoUpdateString(),
 		strings.TrimSpace(b.timeReport),
 		b.updatedString),
-		0, termbox.ColorLightMagenta, termbox.ColorBlack)
-	lastItem := utils.Min(len(b.Files), height+b.SelectedLine)
-	start := b.SelectedLine
-	//l.Print(fmt.Sprintf("Printing from %d to %d", start, lastItem))
-	for y := start; y < lastItem; y++ {
+		0, b.theme.header.fg, b.theme.header.bg)
+	// Files or the terminal size may have changed since the last frame
+	b.setIndex(b.SelectedLine)
+	listWidth := b.Width
+	if b.preview != nil {
+		listWidth = b.Width / 2
+		for y := 1; y < b.Height; y++ {
+			b.screen.SetCell(listWidth, y, '|', termbox.ColorDarkGray, black)
+		}
+		b.renderPreview(b.preview, listWidth+1, b.Width-listWidth-1)
+	}
+	total := totalSize(b.path, b.Files)
+	lastItem := utils.Min(len(b.Files), b.pageSize()+b.offset)
+	for y := b.offset; y < lastItem; y++ {
 		if y > len(b.Files)-1 {
 			break
 		}
 		file := b.Files[y]
-		text := ToString(file)
-		fg := green
-		bg := black
-		if y == b.SelectedLine {
-			fg = black
-			bg = green
-		}
-		b.drawString(text, line, fg, bg)
+		text := ToString(file, listWidth, b.path, total)
+		style := b.theme.fileStyle(file, y == b.SelectedLine)
+		b.drawStringAt(text, 0, line, listWidth, style.fg, style.bg)
 		line++
 	}
 }
 
+func (b *Browser) getReverseString() string {
+	if b.reverse {
+		return ", reversed"
+	}
+	return ""
+}
+
 func (b *Browser) getAutoUpdateString() string {
-	if b.autoUpdateEnabled {
+	switch {
+	case !b.autoUpdateEnabled:
+		return "off"
+	case b.watcher == nil:
+		return "on, polling"
+	case b.watchSubtree:
+		return "on, subfolders"
+	default:
 		return "on"
 	}
-	return "off"
 }
 
-func (b *Browser) wipe() {
-	for y := 0; y < b.Height; y++ {
-		for x := 0; x < b.Width; x++ {
-			termbox.SetCell(x, y, ' ', termbox.ColorBlack, termbox.ColorBlack)
-		}
+// toggleAutoUpdate turns auto update off if it's already on in the same mode, otherwise turns it on
+func (b *Browser) toggleAutoUpdate(subtree bool) {
+	if b.autoUpdateEnabled && b.watchSubtree == subtree {
+		b.autoUpdateEnabled = false
+		b.stopWatching()
+		return
 	}
+	b.autoUpdateEnabled = true
+	b.watchSubtree = subtree
+	b.startWatching()
 }
 
-func (b *Browser) poll() {
-	for {
-		event := termbox.PollEvent()
-		switch event.Type {
-		case termbox.EventKey:
-			b.pollChan <- &event
-		case termbox.EventResize:
-			b.setSize(event.Height, event.Width)
-			b.update()
+func (b *Browser) wipe() {
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			b.screen.SetCell(x, y, ' ', termbox.ColorBlack, termbox.ColorBlack)
 		}
 	}
 }
 
 func (b *Browser) keyPress(e termbox.Event) {
-	switch e.Key {
-	case termbox.KeyArrowUp:
-		b.setIndex(b.SelectedLine - 1)
-		break
-	case termbox.KeyArrowDown:
-		b.setIndex(b.SelectedLine + 1)
-		break
-	case termbox.KeyArrowLeft:
-		b.setIndex(0)
-		b.Select()
-		break
-	case termbox.KeyEnter, termbox.KeyArrowRight:
+	if b.prompt != nil {
+		b.promptKeyPress(e)
+		return
+	}
+	if b.showHelp {
+		// Any key closes the help overlay
+		b.showHelp = false
+		return
+	}
+	if len(b.confirmations) > 0 {
+		b.confirmationKeyPress(e)
+		return
+	}
+	a, ok := b.keys.lookup(e)
+	if !ok {
+		l.Print(fmt.Sprintf("Unhandled Press %+v", e))
+		return
+	}
+	if len(b.Files) == 0 && selectionActions[a] {
+		// Still loading, there's nothing to act on
+		return
+	}
+	if b.preview != nil && b.previewAction(a) {
+		return
+	}
+	if b.navigate(a, len(b.Files), b.pageSize()) {
+		return
+	}
+	switch a {
+	case actionParent:
+		b.up()
+	case actionSelect:
 		b.Select()
-		break
-	case termbox.KeyDelete, termbox.KeyCtrlD:
+	case actionDelete:
 		b.deleteCurrent()
-		break
+	case actionAutoUpdate:
+		b.toggleAutoUpdate(false)
+	case actionAutoUpdateSubtree:
+		b.toggleAutoUpdate(true)
+	case actionHome:
+		// Set to Home Path
+		dirname, err := os.UserHomeDir()
+		b.reportError(err)
+		b.setPath(dirname)
+	case actionSort:
+		b.sort = model.NextSortType(b.sort)
+		b.getFiles(true)
+	case actionReverse:
+		b.reverse = !b.reverse
+		b.getFiles(true)
+	case actionBack:
+		b.back()
+	case actionForward:
+		b.forward()
+	case actionMark:
+		b.askMark()
+	case actionBookmark:
+		b.askBookmark()
+	case actionGoTo:
+		b.askJump()
+	case actionOpen:
+		b.openCurrent()
+	case actionEdit:
+		b.edit()
+	case actionPage:
+		b.page()
+	case actionShell:
+		b.shell()
+	case actionRefresh:
+		b.getFiles(true)
+	case actionRename:
+		b.askRename()
+	case actionMkdir:
+		b.askMkdir()
+	case actionChmod:
+		b.askChmod()
+	case actionCopy:
+		b.yank(false)
+	case actionCut:
+		b.yank(true)
+	case actionPaste:
+		b.paste()
+	case actionHelp:
+		b.showHelp = true
+	case actionQuit:
+		b.quitting = true
+	}
+}
+
+func (b *Browser) confirmationKeyPress(e termbox.Event) {
+	switch e.Ch {
+	case 'n':
+		// Dismiss the pending confirmation
+		b.confirmations = b.confirmations[1:]
+	case 'y':
+		confirmation := b.confirmations[0]
+		b.confirmations = b.confirmations[1:]
+		confirmation.Action()
+	}
+}
+
+// previewAction handles actions while the preview pane is open, returns false if the action wasn't used
+func (b *Browser) previewAction(a action) bool {
+	p := b.preview
+	height := b.previewHeight()
+	switch a {
+	case actionUp:
+		p.ScrollTo(p.Scroll-1, height)
+	case actionDown:
+		p.ScrollTo(p.Scroll+1, height)
+	case actionPageUp:
+		p.ScrollTo(p.Scroll-height, height)
+	case actionPageDown:
+		p.ScrollTo(p.Scroll+height, height)
+	case actionTop:
+		p.ScrollTo(0, height)
+	case actionBottom:
+		p.ScrollTo(len(p.lines()), height)
+	case actionParent, actionClose:
+		b.preview = nil
+	case actionHex:
+		p.ToggleHex()
 	default:
-		switch e.Ch {
-		case 'a':
-			b.autoUpdateEnabled = true
-			break
-		case '~':
-			// Set to Home Path
-			dirname, err := os.UserHomeDir()
-			l.Error(err)
-			b.setPath(dirname)
-			break
-		case 's':
-			switch b.sort {
-			case model.SortSize:
-				b.sort = model.SortName
-				break
-			case model.SortName:
-				b.sort = model.SortSize
-				break
-			}
-			b.getFiles(true)
-			break
-		case 'n':
-			if len(b.confirmations) > 0 {
-				// There is a pending confirmation, remove it
-				b.confirmations = b.confirmations[1:]
-			}
-			break
-		case 'y':
-			if len(b.confirmations) > 0 {
-				// There is a pending confirmation, confirm it
-				confirmation := b.confirmations[0]
-				b.confirmations = b.confirmations[1:]
-				confirmation.Action()
-			}
-			break
-		case 'o':
-			_ = open.Run(b.Files[b.SelectedLine].Path)
-			break
-		case 'r':
-			// Refresh
-			b.getFiles(true)
-			break
-		case '[':
-			b.setIndex(0)
-			break
-		case ']':
-			b.setIndex(len(b.Files) - 1)
-			break
-		default:
-			l.Print(fmt.Sprintf("Unhandled Press %+v", e))
-		}
-		break
+		return false
 	}
+	return true
 }
 
-func (b *Browser) update() {
-	b.pollChan <- nil
+func (b *Browser) mouse(e termbox.Event) {
+	if p := b.preview; p != nil && e.MouseX > b.Width/2 {
+		switch e.Key {
+		case termbox.MouseWheelUp:
+			p.ScrollTo(p.Scroll-wheelStep, b.previewHeight())
+		case termbox.MouseWheelDown:
+			p.ScrollTo(p.Scroll+wheelStep, b.previewHeight())
+		}
+		return
+	}
+	b.cursor.mouse(e, len(b.Files), b.pageSize())
 }
 
 func (b *Browser) kill() {
@@ -332,12 +388,26 @@ func (b *Browser) kill() {
 
 func (b *Browser) close() {
 	l.Print("Closin'!")
-	termbox.Close()
+	if b.cancelLoad != nil {
+		b.cancelLoad()
+	}
+	b.closeScreen()
+	b.stopWatching()
 }
 
 func (b *Browser) setPath(path string) {
+	b.visit(path)
+	b.loadPath(path)
+}
+
+// loadPath shows the given folder without touching the history
+func (b *Browser) loadPath(path string) {
+	b.leave(path)
 	b.path = path
-	b.setIndex(0)
+	b.preview = nil
+	if b.autoUpdateEnabled {
+		b.startWatching()
+	}
 	b.getFiles(true)
 }
 
@@ -346,35 +416,37 @@ func (b *Browser) getCurrentFile() File {
 }
 
 func (b *Browser) Select() {
-	newPath := b.getCurrentFile().Path
-	l.Print("Selecting " + newPath)
-	b.setPath(newPath)
+	current := b.getCurrentFile()
+	l.Print("Selecting " + current.Path)
+	if !current.Dir {
+		b.preview = NewPreview(current.Path)
+		return
+	}
+	b.setPath(current.Path)
 }
 
-func (b *Browser) setSize(height int, width int) {
-	l.Print(fmt.Sprintf("Setting Size to %dx%d", height, width))
-	b.Height = height
-	b.Width = width
+func (b *Browser) setIndex(i int) {
+	b.moveTo(i, len(b.Files), b.pageSize())
 }
 
-func (b *Browser) setIndex(i int) {
-	if i < 0 {
-		i = 0
+// pageSize is the number of file rows that fit on the screen below the header
+func (b *Browser) pageSize() int {
+	// Header and status bar
+	h := b.Height - 2
+	if b.prompt != nil || b.operation != nil {
+		h--
 	}
-	if i >= len(b.Files) {
-		i = len(b.Files) - 1
+	if h > 0 {
+		return h
 	}
-	b.SelectedLine = i
+	return 1
 }
 
 func (b *Browser) deleteCurrent() {
 	path := b.getCurrentFile().Path
-	b.confirmations = append(b.confirmations, model.Confirmation{
-		Message: fmt.Sprintf("Are you sure you want to delete %s?", path),
-		Action: func() {
-			l.Print(fmt.Sprintf("Deleting %s", path))
-			l.Error(os.RemoveAll(path))
-			b.getFiles(true)
-		},
+	b.confirm(fmt.Sprintf("Are you sure you want to delete %s?", path), func() {
+		l.Print(fmt.Sprintf("Deleting %s", path))
+		b.reportError(os.RemoveAll(path))
+		b.getFiles(true)
 	})
 }
@@ -0,0 +1,347 @@
+package browser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamackay/all/browser/browsertest"
+	"github.com/kamackay/all/files"
+	"github.com/nsf/termbox-go"
+)
+
+// Entries of testdata by size, the default sort
+var bySize = []string{"zebra.log", "poem.txt", "data.bin", "notes", "alpha.txt"}
+
+// start runs the browser on root with a fresh config folder so the user's keys and bookmarks don't leak in
+func start(t *testing.T, root string, width, height int) *browsertest.Harness {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	h := browsertest.New(t, root, width, height)
+	t.Cleanup(h.Quit)
+	return h
+}
+
+// scratch is a copy of testdata for tests that change files
+func scratch(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "testdata")
+	if err := files.Copy("testdata", dir, func(int64) {}); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// selected is the text of the highlighted row
+func selected(t *testing.T, h *browsertest.Harness) string {
+	t.Helper()
+	lines := h.Screen.Lines()
+	for y := 1; y < len(lines); y++ {
+		if h.Screen.Cell(0, y).Bg == termbox.ColorGreen {
+			return lines[y]
+		}
+	}
+	t.Fatalf("Nothing is selected, screen:\n%s", h.Text())
+	return ""
+}
+
+func assertSelected(t *testing.T, h *browsertest.Harness, name string) {
+	t.Helper()
+	if line := selected(t, h); !strings.Contains(line, name) {
+		t.Fatalf("Selected %q, expected %s, screen:\n%s", line, name, h.Text())
+	}
+}
+
+func TestListsFolderBySize(t *testing.T) {
+	h := start(t, "testdata", 100, 20)
+	h.AssertLine(0, "Current: testdata (Sorting by filesize)")
+	h.AssertLine(1, "..")
+	for i, name := range bySize {
+		h.AssertLine(i+2, name)
+	}
+	h.AssertLine(5, "#1")
+	assertSelected(t, h, "..")
+}
+
+func TestNavigation(t *testing.T) {
+	h := start(t, "testdata", 100, 20)
+	h.Press("j", "j")
+	assertSelected(t, h, "poem.txt")
+	h.Press("k")
+	assertSelected(t, h, "zebra.log")
+	h.Press("End")
+	assertSelected(t, h, "alpha.txt")
+	h.Press("Home")
+	assertSelected(t, h, "..")
+
+	// Into a folder and back out again, landing on the folder that was left
+	h.Press("j", "j", "j", "j", "Enter")
+	h.WaitFor("Current: testdata/notes")
+	h.AssertLine(2, "todo.txt")
+	h.Press("Left")
+	h.WaitFor("Current: testdata ")
+	assertSelected(t, h, "notes")
+
+	// History
+	h.Press("h")
+	h.WaitFor("Current: testdata/notes")
+	h.Press("l")
+	h.WaitFor("Current: testdata ")
+}
+
+func TestScrolling(t *testing.T) {
+	// Room for three rows between the header and the status bar
+	h := start(t, "testdata", 100, 5)
+	h.AssertLine(1, "..")
+	h.AssertLine(3, "poem.txt")
+	h.Press("End")
+	assertSelected(t, h, "alpha.txt")
+	h.AssertLine(1, "data.bin")
+	h.AssertLine(3, "alpha.txt")
+	h.Press("PgUp")
+	assertSelected(t, h, "poem.txt")
+	h.AssertLine(1, "poem.txt")
+
+	// The wheel moves the view and drags the selection along
+	h.Press("Home")
+	h.Screen.Inject(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseWheelDown, MouseY: 2})
+	h.Press()
+	h.AssertLine(1, "data.bin")
+	assertSelected(t, h, "data.bin")
+
+	// Clicking selects the row
+	h.Screen.Inject(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: 3})
+	h.Press()
+	assertSelected(t, h, "alpha.txt")
+}
+
+func TestSortCycling(t *testing.T) {
+	h := start(t, "testdata", 100, 20)
+	h.Press("s")
+	h.WaitFor("Sorting by name")
+	for i, name := range []string{"alpha.txt", "data.bin", "notes", "poem.txt", "zebra.log"} {
+		h.AssertLine(i+2, name)
+	}
+	h.Press("S")
+	h.WaitFor("Sorting by name, reversed")
+	h.AssertLine(2, "zebra.log")
+	h.AssertLine(6, "alpha.txt")
+	h.Press("S")
+	for _, mode := range []string{"modified time", "child count", "extension", "directories first", "filesize"} {
+		h.Press("s")
+		h.WaitFor("Sorting by " + mode + ")")
+	}
+	for i, name := range bySize {
+		h.AssertLine(i+2, name)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	h := start(t, "testdata", 120, 20)
+	h.Press("j", "j", "Enter")
+	h.WaitFor("[text] lines 1-15 of 41")
+	if !strings.Contains(h.Text(), " 1 | line 01 of a poem") {
+		t.Fatalf("First line of the poem isn't shown:\n%s", h.Text())
+	}
+	h.Press("j")
+	h.WaitFor("lines 2-16 of 41")
+	h.Press("End")
+	h.WaitFor("lines 27-41 of 41")
+	h.Press("x")
+	h.WaitFor("[text, hex]")
+	h.Press("Esc")
+	if strings.Contains(h.Text(), "[text") {
+		t.Fatalf("Preview is still open:\n%s", h.Text())
+	}
+	assertSelected(t, h, "poem.txt")
+
+	// Binary files are always hex
+	h.Press("j", "Enter")
+	h.WaitFor("[binary, hex]")
+	h.WaitFor("00 01 02 03")
+}
+
+func TestNarrowPreviewKeepsNames(t *testing.T) {
+	h := start(t, "testdata", 100, 20)
+	h.Press("j", "j", "Enter")
+	h.WaitFor("[text]")
+	for i, name := range bySize {
+		h.AssertLine(i+2, name)
+	}
+	h.Resize(60, 20)
+	h.WaitFor("[text]")
+	for i, name := range bySize {
+		h.AssertLine(i+2, name)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	h := start(t, "testdata", 100, 50)
+	h.Press("?")
+	h.AssertLine(0, "Keys (change them in config.toml)")
+	h.WaitFor("Cycle sort mode")
+	h.Press("j")
+	h.AssertLine(0, "Current: testdata")
+	// Any key only closes the help, it doesn't move
+	assertSelected(t, h, "..")
+}
+
+func TestGoToPrompt(t *testing.T) {
+	h := start(t, "testdata", 100, 20)
+	h.Press("g")
+	h.WaitFor("Go to: testdata/")
+	h.Type("no")
+	h.Press("Tab")
+	h.WaitFor("Go to: testdata/notes/")
+	h.Press("Enter")
+	h.WaitFor("testdata/notes (Sorting")
+
+	// Esc leaves without going anywhere
+	h.Press("g")
+	h.Type("elsewhere")
+	h.Press("Esc")
+	if strings.Contains(h.Text(), "Go to:") {
+		t.Fatalf("Prompt is still open:\n%s", h.Text())
+	}
+	h.AssertLine(0, "testdata/notes (Sorting")
+}
+
+func TestFilePrompts(t *testing.T) {
+	dir := scratch(t)
+	h := start(t, dir, 120, 20)
+
+	h.Press("+")
+	h.Type("fresh")
+	h.Press("Enter")
+	h.WaitFor("fresh")
+	if info, err := os.Stat(filepath.Join(dir, "fresh")); err != nil || !info.IsDir() {
+		t.Fatalf("New folder wasn't made: %v", err)
+	}
+
+	h.Press("s")
+	h.WaitFor("Sorting by name")
+	h.Press("Home", "j")
+	assertSelected(t, h, "alpha.txt")
+	h.Press("R")
+	h.WaitFor("Rename to: alpha.txt")
+	for range "alpha.txt" {
+		h.Press("Backspace")
+	}
+	h.Type("omega.txt")
+	h.Press("Enter")
+	h.WaitFor("Press y to confirm")
+	h.Press("n")
+	h.WaitFor("alpha.txt")
+	if _, err := os.Stat(filepath.Join(dir, "alpha.txt")); err != nil {
+		t.Fatalf("Dismissed rename still happened: %v", err)
+	}
+
+	h.Press("Delete")
+	h.WaitFor("Are you sure you want to delete")
+	h.Press("y")
+	h.WaitFor("Current:")
+	if _, err := os.Stat(filepath.Join(dir, "alpha.txt")); !os.IsNotExist(err) {
+		t.Fatalf("alpha.txt wasn't deleted: %v", err)
+	}
+}
+
+func TestCopyPaste(t *testing.T) {
+	dir := scratch(t)
+	h := start(t, dir, 120, 20)
+	h.Press("j")
+	assertSelected(t, h, "zebra.log")
+	h.Press("c", "j", "j", "j", "Enter")
+	h.WaitFor("Current: " + filepath.Join(dir, "notes"))
+	h.Press("v")
+	h.WaitFor("Copy " + filepath.Join(dir, "zebra.log"))
+	h.Press("y")
+	h.WaitFor("zebra.log")
+	copied, err := os.ReadFile(filepath.Join(dir, "notes", "zebra.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, _ := os.ReadFile(filepath.Join(dir, "zebra.log"))
+	if string(copied) != string(original) {
+		t.Fatalf("Copy doesn't match the original")
+	}
+}
+
+func TestPasteOverParentIsRefused(t *testing.T) {
+	dir := scratch(t)
+	inner := filepath.Join(dir, "notes", "notes")
+	if err := os.MkdirAll(inner, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inner, "keep.txt"), []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := start(t, dir, 240, 20)
+	h.Press("j", "j", "j", "j")
+	assertSelected(t, h, "notes")
+	h.Press("Enter")
+	h.WaitFor("Current: " + filepath.Join(dir, "notes"))
+	h.Press("j", "j")
+	assertSelected(t, h, "notes")
+	for _, key := range []string{"c", "X"} {
+		h.Press(key, "Left")
+		h.WaitFor("Current: " + dir + " ")
+		// Pasting notes/notes here would replace notes, and notes/notes with it
+		h.Press("v")
+		h.WaitFor("Can't paste " + inner)
+		h.Press("y")
+		if _, err := os.Stat(filepath.Join(inner, "keep.txt")); err != nil {
+			t.Fatalf("Pasting with %s lost the file: %v", key, err)
+		}
+		h.Press("Enter")
+		h.WaitFor("Current: " + filepath.Join(dir, "notes"))
+	}
+}
+
+func TestWatchPicksUpNewFiles(t *testing.T) {
+	dir := scratch(t)
+	h := start(t, dir, 120, 20)
+	h.Press("a")
+	h.WaitFor("[Auto Update: on]")
+	if err := os.WriteFile(filepath.Join(dir, "arrived.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h.WaitFor("arrived.txt")
+}
+
+func TestWatchRefreshesWhileFilesKeepChanging(t *testing.T) {
+	dir := scratch(t)
+	h := start(t, dir, 160, 20)
+	h.Press("a")
+	h.WaitFor("[Auto Update: on]")
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log, err := os.OpenFile(filepath.Join(dir, "zebra.log"), os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer log.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(20 * time.Millisecond):
+				_, _ = log.WriteString("another line\n")
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "arrived.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h.WaitFor("arrived.txt")
+}
@@ -0,0 +1,174 @@
+// Package browsertest drives the browser without a terminal, for scripting key presses and checking what's on screen.
+//
+// Tests should point XDG_CONFIG_HOME at an empty folder so the user's config.toml and bookmarks aren't picked up:
+//
+//	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+//	h := browsertest.New(t, dir, 100, 30)
+//	defer h.Quit()
+//	h.Press("j", "Enter")
+//	h.WaitFor("main.go")
+package browsertest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kamackay/all/browser"
+	"github.com/nsf/termbox-go"
+)
+
+const (
+	// How long the screen has to go without a new frame before the browser counts as idle
+	settleTime = 30 * time.Millisecond
+	// How long to wait for the browser before failing
+	timeout = 5 * time.Second
+)
+
+// TB is the part of testing.TB the harness uses
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+type Harness struct {
+	t       TB
+	Screen  *browser.MemoryScreen
+	Browser *browser.Browser
+	Compare *browser.Compare
+	done    chan struct{}
+}
+
+// New starts a browser on root with an in-memory screen of the given size and waits for the first load
+func New(t TB, root string, width, height int) *Harness {
+	t.Helper()
+	screen := browser.NewMemoryScreen(width, height)
+	b, err := browser.NewWithScreen(root, screen)
+	if err != nil {
+		t.Fatalf("Could not start browser: %+v", err)
+	}
+	h := &Harness{t: t, Screen: screen, Browser: b, done: make(chan struct{})}
+	h.run(b.Run)
+	h.WaitFor("Done in")
+	return h
+}
+
+// NewCompare starts a comparison of the two folders with an in-memory screen and waits for the first comparison
+func NewCompare(t TB, left, right string, width, height int) *Harness {
+	t.Helper()
+	screen := browser.NewMemoryScreen(width, height)
+	c, err := browser.NewCompareWithScreen(left, right, screen)
+	if err != nil {
+		t.Fatalf("Could not start comparison: %+v", err)
+	}
+	h := &Harness{t: t, Screen: screen, Compare: c, done: make(chan struct{})}
+	h.run(c.Run)
+	h.WaitFor(" same")
+	return h
+}
+
+func (h *Harness) run(loop func()) {
+	go func() {
+		defer close(h.done)
+		loop()
+	}()
+}
+
+// Press sends each key in turn, using the names from config.toml like "j", "Enter" or "Ctrl+D",
+// and returns once the browser has drawn the result
+func (h *Harness) Press(keys ...string) {
+	h.t.Helper()
+	for _, key := range keys {
+		event, err := browser.KeyEvent(key)
+		if err != nil {
+			h.t.Fatalf("%+v", err)
+		}
+		h.Screen.Inject(event)
+	}
+	h.settle()
+}
+
+// Type sends text one character at a time, for filling in prompts
+func (h *Harness) Type(text string) {
+	h.t.Helper()
+	for _, r := range text {
+		event := termbox.Event{Type: termbox.EventKey, Ch: r}
+		if r == ' ' {
+			event = termbox.Event{Type: termbox.EventKey, Key: termbox.KeySpace}
+		}
+		h.Screen.Inject(event)
+	}
+	h.settle()
+}
+
+// Resize changes the size of the screen and waits for the browser to redraw
+func (h *Harness) Resize(width, height int) {
+	h.t.Helper()
+	h.Screen.Resize(width, height)
+	h.settle()
+}
+
+// settle waits until every injected event has been read and no new frames are being drawn
+func (h *Harness) settle() {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	last := h.Screen.Flushes()
+	quietSince := time.Now()
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 5)
+		if flushes := h.Screen.Flushes(); flushes != last || h.Screen.Pending() > 0 {
+			last = flushes
+			quietSince = time.Now()
+			continue
+		}
+		if time.Since(quietSince) >= settleTime {
+			return
+		}
+	}
+	h.t.Fatalf("Browser didn't settle within %s, screen:\n%s", timeout, h.Screen)
+}
+
+// WaitFor waits until text shows up anywhere on screen
+func (h *Harness) WaitFor(text string) {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if strings.Contains(h.Screen.String(), text) {
+			return
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+	h.t.Fatalf("%q never showed up on screen:\n%s", text, h.Screen)
+}
+
+// AssertLine fails unless row y of the screen contains text
+func (h *Harness) AssertLine(y int, text string) {
+	h.t.Helper()
+	lines := h.Screen.Lines()
+	if y < 0 || y >= len(lines) {
+		h.t.Fatalf("Line %d is off screen, it has %d lines", y, len(lines))
+	}
+	if !strings.Contains(lines[y], text) {
+		h.t.Fatalf("Line %d is %q, expected it to contain %q, screen:\n%s", y, lines[y], text, h.Screen)
+	}
+}
+
+// Text is the whole screen as it was last drawn
+func (h *Harness) Text() string {
+	return h.Screen.String()
+}
+
+// Quit stops the browser or comparison and waits for Run to return
+func (h *Harness) Quit() {
+	h.t.Helper()
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+	h.Screen.Inject(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyCtrlC})
+	select {
+	case <-h.done:
+	case <-time.After(timeout):
+		h.t.Fatalf("Browser didn't quit within %s, screen:\n%s", timeout, h.Screen)
+	}
+}
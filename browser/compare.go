@@ -0,0 +1,439 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/l"
+	"github.com/kamackay/all/utils"
+	"github.com/nsf/termbox-go"
+)
+
+// difference is how an entry in the left folder relates to the one with the same name in the right folder
+type difference int
+
+const (
+	same difference = iota
+	// Only in the left folder
+	missing
+	// Only in the right folder
+	extra
+	different
+)
+
+var differenceMarks = map[difference]string{
+	same:      " ",
+	missing:   "-",
+	extra:     "+",
+	different: "*",
+}
+
+type compareEntry struct {
+	Name  string
+	Left  *File
+	Right *File
+	State difference
+}
+
+func (e compareEntry) dir() bool {
+	return e.Name == ".." || e.Left != nil && e.Left.Dir || e.Right != nil && e.Right.Dir
+}
+
+type compareResult struct {
+	id      uint64
+	entries []compareEntry
+	err     error
+}
+
+// Compare shows two folders side by side, walking both of them in lockstep
+type Compare struct {
+	view
+	cursor
+	left, right string
+	rel         string
+	entries     []compareEntry
+	hash        bool
+	loading     bool
+	loadID      uint64
+	cancelLoad  context.CancelFunc
+	selectName  string
+}
+
+func NewCompare(left, right string) (*Compare, error) {
+	return NewCompareWithScreen(left, right, termboxScreen{})
+}
+
+// NewCompareWithScreen creates a comparison of the two folders that draws to the given screen
+func NewCompareWithScreen(left, right string, screen Screen) (*Compare, error) {
+	for _, dir := range []string{left, right} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a folder", dir)
+		}
+	}
+	v, err := newView(screen)
+	if err != nil {
+		return nil, err
+	}
+	c := &Compare{
+		view:  v,
+		left:  left,
+		right: right,
+		rel:   ".",
+	}
+	c.configure()
+	c.load()
+	return c, nil
+}
+
+func (c *Compare) Run() {
+	defer c.close()
+	go c.poll()
+	for !c.quitting {
+		c.Render()
+		c.handle(<-c.messages)
+	}
+}
+
+func (c *Compare) close() {
+	if c.cancelLoad != nil {
+		c.cancelLoad()
+	}
+	c.closeScreen()
+}
+
+func (c *Compare) handle(msg interface{}) {
+	switch m := msg.(type) {
+	case inputMsg:
+		if m.event.Key == termbox.KeyCtrlC {
+			c.quitting = true
+		} else if m.event.Type == termbox.EventMouse {
+			c.cursor.mouse(m.event, len(c.entries), c.pageSize())
+		} else {
+			c.keyPress(m.event)
+		}
+	case resizeMsg:
+		c.setSize(m.height, m.width)
+		c.setIndex(c.SelectedLine)
+	case compareResult:
+		c.loaded(m)
+	default:
+		l.Print(fmt.Sprintf("Unknown message %+v", msg))
+	}
+}
+
+func (c *Compare) keyPress(e termbox.Event) {
+	if c.showHelp {
+		c.showHelp = false
+		return
+	}
+	a, ok := c.keys.lookup(e)
+	if !ok || c.navigate(a, len(c.entries), c.pageSize()) {
+		return
+	}
+	switch a {
+	case actionParent, actionBack:
+		c.up()
+	case actionSelect:
+		c.Select()
+	case actionNextDifference:
+		c.nextDifference(1)
+	case actionPrevDifference:
+		c.nextDifference(-1)
+	case actionCompareHash:
+		c.hash = !c.hash
+		c.reload()
+	case actionRefresh:
+		c.reload()
+	case actionHelp:
+		c.showHelp = true
+	case actionQuit:
+		c.quitting = true
+	}
+}
+
+// Select goes into the selected folder on both sides, even if it only exists on one of them
+func (c *Compare) Select() {
+	if c.SelectedLine < 0 || c.SelectedLine >= len(c.entries) {
+		return
+	}
+	entry := c.entries[c.SelectedLine]
+	if entry.Name == ".." {
+		c.up()
+		return
+	}
+	if !entry.dir() {
+		return
+	}
+	c.rel = filepath.Join(c.rel, entry.Name)
+	c.selectName = ""
+	c.load()
+}
+
+func (c *Compare) up() {
+	if c.rel == "." {
+		return
+	}
+	c.selectName = filepath.Base(c.rel)
+	c.rel = filepath.Dir(c.rel)
+	c.load()
+}
+
+// reload compares the current folders again, keeping the cursor on the same entry
+func (c *Compare) reload() {
+	if c.SelectedLine >= 0 && c.SelectedLine < len(c.entries) {
+		c.selectName = c.entries[c.SelectedLine].Name
+	}
+	c.load()
+}
+
+// nextDifference moves the cursor to the next entry in the given direction that isn't the same on both sides
+func (c *Compare) nextDifference(step int) {
+	for i := c.SelectedLine + step; i >= 0 && i < len(c.entries); i += step {
+		if c.entries[i].State != same {
+			c.setIndex(i)
+			return
+		}
+	}
+}
+
+// load compares the current folders in the background, cancelling any comparison that's still running
+func (c *Compare) load() {
+	if c.cancelLoad != nil {
+		c.cancelLoad()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelLoad = cancel
+	c.loadID++
+	c.loading = true
+	id, left, right, hash := c.loadID, filepath.Join(c.left, c.rel), filepath.Join(c.right, c.rel), c.hash
+	root := c.rel == "."
+	go func() {
+		entries, err := compareFolders(ctx, left, right, hash)
+		if !root {
+			entries = append([]compareEntry{{Name: ".."}}, entries...)
+		}
+		c.send(ctx, compareResult{id: id, entries: entries, err: err})
+	}()
+}
+
+func (c *Compare) loaded(r compareResult) {
+	if r.id != c.loadID {
+		return
+	}
+	c.cancelLoad = nil
+	c.loading = false
+	c.reportError(r.err)
+	c.entries = r.entries
+	c.offset = 0
+	c.setIndex(0)
+	for i, entry := range c.entries {
+		if entry.Name == c.selectName {
+			c.setIndex(i)
+			break
+		}
+	}
+	c.selectName = ""
+}
+
+// compareFolders lists the entries of both folders by name. A folder that doesn't exist counts as empty,
+// so descending into a folder that's only on one side shows everything in it as missing or extra
+func compareFolders(ctx context.Context, left, right string, hash bool) ([]compareEntry, error) {
+	leftFiles, err := readFolder(ctx, left)
+	if err != nil {
+		return nil, err
+	}
+	rightFiles, err := readFolder(ctx, right)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*compareEntry)
+	for name, file := range leftFiles {
+		byName[name] = &compareEntry{Name: name, Left: file}
+	}
+	for name, file := range rightFiles {
+		if entry, ok := byName[name]; ok {
+			entry.Right = file
+		} else {
+			byName[name] = &compareEntry{Name: name, Right: file}
+		}
+	}
+	entries := make([]compareEntry, 0, len(byName))
+	for _, entry := range byName {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		entry.State = compareFiles(entry.Left, entry.Right, hash)
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].dir() != entries[j].dir() {
+			return entries[i].dir()
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries, nil
+}
+
+func readFolder(ctx context.Context, dir string) (map[string]*File, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := make(map[string]*File, len(entries))
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Deleted since the folder was read
+			continue
+		}
+		file := makeFile(dir, info)
+		list[entry.Name()] = &file
+	}
+	return list, nil
+}
+
+// compareFiles decides whether two entries match. Folders are compared by their total size, files by size
+// and then either their contents or their modified time to the second, since copies often lose the fraction
+func compareFiles(left, right *File, hash bool) difference {
+	switch {
+	case right == nil:
+		return missing
+	case left == nil:
+		return extra
+	case left.Dir != right.Dir || left.Size != right.Size:
+		return different
+	case left.Dir:
+		return same
+	case hash:
+		leftHash, err := files.Hash(left.Path)
+		if err != nil {
+			l.Error(err)
+			return different
+		}
+		rightHash, err := files.Hash(right.Path)
+		if err != nil {
+			l.Error(err)
+			return different
+		}
+		if leftHash != rightHash {
+			return different
+		}
+		return same
+	case !left.ModTime.Truncate(time.Second).Equal(right.ModTime.Truncate(time.Second)):
+		return different
+	default:
+		return same
+	}
+}
+
+// pageSize is the number of rows between the header and the status bar
+func (c *Compare) pageSize() int {
+	if h := c.Height - 2; h > 0 {
+		return h
+	}
+	return 1
+}
+
+func (c *Compare) setIndex(i int) {
+	c.moveTo(i, len(c.entries), c.pageSize())
+}
+
+func (c *Compare) Render() {
+	l.Error(c.screen.Clear(termbox.ColorWhite, termbox.ColorDefault))
+	c.screen.HideCursor()
+	defer c.screen.Flush()
+	defer c.renderStatus()
+	if c.showHelp {
+		c.renderHelp()
+		return
+	}
+	mode := "modified time"
+	if c.hash {
+		mode = "contents"
+	}
+	header := fmt.Sprintf("Compare: %s  vs  %s (by size and %s)", filepath.Join(c.left, c.rel),
+		filepath.Join(c.right, c.rel), mode)
+	c.drawString(header, 0, c.theme.header.fg, c.theme.header.bg)
+	if c.loading {
+		c.drawString("Comparing...", 8, green, black)
+		return
+	}
+	last := utils.Min(len(c.entries), c.offset+c.pageSize())
+	for i := c.offset; i < last; i++ {
+		entry := c.entries[i]
+		s := c.entryStyle(entry, i == c.SelectedLine)
+		c.drawString(c.row(entry), 1+i-c.offset, s.fg, s.bg)
+	}
+}
+
+// row lays out an entry as its mark and name followed by the size, and the modified time if there's room, on each side
+func (c *Compare) row(entry compareEntry) string {
+	showTime := c.Width >= 2*(sizeColumnWidth+timeColumnWidth)+40
+	side := func(file *File) string {
+		size, modified := "", ""
+		if file != nil {
+			size = utils.FormatSize(uint64(file.Size), true)
+			modified = file.LastModified
+		}
+		text := padLeft(size, sizeColumnWidth)
+		if showTime {
+			text += columnGap + padRight(modified, timeColumnWidth)
+		}
+		return text
+	}
+	if entry.Name == ".." {
+		return "  .."
+	}
+	sides := columnGap + side(entry.Left) + " | " + side(entry.Right)
+	name := entry.Name
+	if entry.dir() {
+		name += string(filepath.Separator)
+	}
+	nameWidth := c.Width - 2 - textWidth(sides)
+	return differenceMarks[entry.State] + " " + padRight(truncateMiddle(name, nameWidth), nameWidth) + sides
+}
+
+func (c *Compare) entryStyle(entry compareEntry, selected bool) style {
+	switch {
+	case selected:
+		return c.theme.selection
+	case entry.State == missing:
+		return style{termbox.ColorRed, black}
+	case entry.State == extra:
+		return style{termbox.ColorYellow, black}
+	case entry.State == different:
+		return style{termbox.ColorCyan, black}
+	case entry.dir():
+		return c.theme.directory
+	default:
+		return c.theme.file
+	}
+}
+
+// renderStatus counts the differences in the current folder on the bottom line
+func (c *Compare) renderStatus() {
+	counts := make(map[difference]int)
+	for _, entry := range c.entries {
+		if entry.Name != ".." {
+			counts[entry.State]++
+		}
+	}
+	c.renderStatusBar(fmt.Sprintf("- %d missing   + %d extra   * %d different   %d same", counts[missing], counts[extra],
+		counts[different], counts[same]), "")
+}
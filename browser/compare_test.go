@@ -0,0 +1,106 @@
+package browser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamackay/all/browser/browsertest"
+	"github.com/nsf/termbox-go"
+)
+
+// writeTree creates files under dir with the given contents, all modified at the same time
+func writeTree(t *testing.T, dir string, contents map[string]string) {
+	t.Helper()
+	modified := time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	for name, text := range contents {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func startCompare(t *testing.T) (*browsertest.Harness, string, string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	left, right := t.TempDir(), t.TempDir()
+	writeTree(t, left, map[string]string{
+		"same.txt":      "same",
+		"stamp.txt":     "left",
+		"changed.txt":   "short",
+		"only-left.txt": "left",
+		"sub/inner.txt": "inner",
+	})
+	writeTree(t, right, map[string]string{
+		"same.txt":       "same",
+		"stamp.txt":      "rite",
+		"changed.txt":    "much longer",
+		"only-right.txt": "right",
+		"sub/inner.txt":  "inner, but longer",
+	})
+	h := browsertest.NewCompare(t, left, right, 140, 20)
+	t.Cleanup(h.Quit)
+	return h, left, right
+}
+
+func TestCompare(t *testing.T) {
+	h, _, _ := startCompare(t)
+	h.AssertLine(1, "* sub/")
+	h.AssertLine(2, "* changed.txt")
+	h.AssertLine(3, "- only-left.txt")
+	h.AssertLine(4, "+ only-right.txt")
+	h.AssertLine(5, "  same.txt")
+	h.AssertLine(6, "  stamp.txt")
+	h.AssertLine(19, "- 1 missing   + 1 extra   * 2 different   2 same")
+	assertSelected(t, h, "sub/")
+
+	h.Press("n", "n")
+	assertSelected(t, h, "only-left.txt")
+	h.Press("N")
+	assertSelected(t, h, "changed.txt")
+
+	// Contents catch the file that only looks the same by size and time
+	h.Press("#")
+	h.WaitFor("(by size and contents)")
+	h.WaitFor("* 3 different   1 same")
+	h.AssertLine(6, "* stamp.txt")
+	assertSelected(t, h, "changed.txt")
+}
+
+func TestCompareFolders(t *testing.T) {
+	h, left, right := startCompare(t)
+	h.Press("Enter")
+	h.WaitFor(filepath.Join(left, "sub") + "  vs  " + filepath.Join(right, "sub"))
+	h.WaitFor("* inner.txt")
+	h.AssertLine(1, "..")
+	h.Press("Left")
+	h.WaitFor("* changed.txt")
+	assertSelected(t, h, "sub/")
+}
+
+func TestCompareInput(t *testing.T) {
+	h, _, _ := startCompare(t)
+	h.Screen.Inject(termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseY: 4})
+	h.Press()
+	assertSelected(t, h, "only-right.txt")
+	h.Press("End")
+	assertSelected(t, h, "stamp.txt")
+
+	h.Press("?")
+	h.AssertLine(0, "Keys (change them in config.toml)")
+	h.Press("j")
+	assertSelected(t, h, "stamp.txt")
+
+	h.Resize(60, 5)
+	assertSelected(t, h, "stamp.txt")
+	h.AssertLine(3, "stamp.txt")
+}
@@ -0,0 +1,182 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/l"
+	"github.com/kamackay/all/model"
+	"github.com/nsf/termbox-go"
+)
+
+// Messages handled by the event loop in Run. All of the browser's state is owned by the loop,
+// goroutines only hand it finished results and never touch the browser directly
+
+type inputMsg struct {
+	event termbox.Event
+}
+
+type resizeMsg struct {
+	width, height int
+}
+
+type loadProgressMsg struct {
+	id   uint64
+	info model.LoadingInfo
+}
+
+// loadedMsg is a finished folder load, it's dropped if another load was started since
+type loadedMsg struct {
+	id        uint64
+	path      string
+	files     []File
+	took      time.Duration
+	diskUsage string
+}
+
+// changedMsg is a file system event under root
+type changedMsg struct {
+	root string
+	path string
+}
+
+// entriesMsg has fresh copies of the entries of root that changed, changed entries that are missing were deleted
+type entriesMsg struct {
+	root    string
+	changed map[string]bool
+	files   []File
+}
+
+type progressMsg struct {
+	op    *model.Operation
+	done  int64
+	total int64
+}
+
+type operationDoneMsg struct {
+	op  *model.Operation
+	err error
+}
+
+type errorMsg struct {
+	err error
+}
+
+// Room for bursts of progress updates without holding up the goroutines sending them
+const messageBuffer = 64
+
+func (b *Browser) handle(msg interface{}) {
+	switch m := msg.(type) {
+	case inputMsg:
+		if m.event.Key == termbox.KeyCtrlC {
+			b.quitting = true
+		} else if m.event.Type == termbox.EventMouse {
+			b.mouse(m.event)
+		} else {
+			b.keyPress(m.event)
+		}
+	case resizeMsg:
+		b.setSize(m.height, m.width)
+	case loadProgressMsg:
+		if m.id == b.loadID && b.loading != nil {
+			info := m.info
+			b.loading = &info
+		}
+	case loadedMsg:
+		b.loaded(m)
+	case changedMsg:
+		b.changed(m.root, m.path)
+	case entriesMsg:
+		b.refreshed(m)
+	case progressMsg:
+		if b.operation == m.op {
+			b.operation.Done = m.done
+			b.operation.Total = m.total
+		}
+	case operationDoneMsg:
+		b.reportError(m.err)
+		if b.operation == m.op {
+			b.operation = nil
+		}
+		b.getFiles(false)
+	case errorMsg:
+		b.reportError(m.err)
+	default:
+		l.Print(fmt.Sprintf("Unknown message %+v", msg))
+	}
+}
+
+// getFiles starts loading the current folder in the background, cancelling any load that's still running
+func (b *Browser) getFiles(render bool) {
+	// Reloads keep the cursor on the same entry even if it moves in the list
+	b.rememberPosition()
+	if b.cancelLoad != nil {
+		b.cancelLoad()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancelLoad = cancel
+	b.loadID++
+	b.loading = &model.LoadingInfo{Render: render}
+	go b.load(ctx, b.loadID, b.path, b.sort, b.reverse, render)
+}
+
+// load reads a folder, it runs on its own goroutine so it only talks to the browser through messages
+func (b *Browser) load(ctx context.Context, id uint64, path string, sortType model.SortType, reverse bool, render bool) {
+	start := time.Now()
+	l.Print(fmt.Sprintf("Pulling files for %s", path))
+	fs := files.GetFiles(path)
+	l.Print(fmt.Sprintf("Pulled %d files for %s", len(fs), path))
+	fileList := make([]File, 0, len(fs))
+	for x, f := range fs {
+		if ctx.Err() != nil {
+			l.Print(fmt.Sprintf("Stopped loading %s", path))
+			return
+		}
+		b.trySend(loadProgressMsg{id: id, info: model.LoadingInfo{
+			Item:    x,
+			Total:   len(fs),
+			Current: f.Name(),
+			Render:  render,
+		}})
+		fileList = append(fileList, makeFile(path, f))
+	}
+	sortFiles(fileList, sortType, reverse)
+	fileList = append([]File{
+		makeRelativeFile(path, ".."),
+	}, fileList...)
+	b.send(ctx, loadedMsg{
+		id:        id,
+		path:      path,
+		files:     fileList,
+		took:      time.Since(start),
+		diskUsage: diskUsage(path),
+	})
+}
+
+func (b *Browser) loaded(m loadedMsg) {
+	if m.id != b.loadID {
+		return
+	}
+	b.cancelLoad = nil
+	b.loading = nil
+	if m.took < time.Millisecond*200 {
+		// Updating this folder is pretty quick, update it more frequently
+		b.reloadInterval = time.Second
+	} else {
+		b.reloadInterval = time.Second * 5
+	}
+	if m.took > time.Second {
+		now := time.Now()
+		b.timeReport = fmt.Sprintf("Done in %s", humanize.RelTime(now.Add(-m.took), now, "", ""))
+	} else {
+		b.timeReport = fmt.Sprintf("Done in %dms", m.took.Milliseconds())
+	}
+	b.updatedString = time.Now().Format("2006-01-02 15:04:05")
+	b.Files = m.files
+	b.filesPath = m.path
+	b.diskUsage = m.diskUsage
+	b.restorePosition()
+}
@@ -5,8 +5,11 @@ import (
 	"github.com/kamackay/all/files"
 	"github.com/kamackay/all/l"
 	"github.com/kamackay/all/utils"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 type File struct {
@@ -14,22 +17,108 @@ type File struct {
 	Size         int64
 	Dir          bool
 	LastModified string
+	ModTime      time.Time
 	ToString     func() string
 	Children     uint
 }
 
-func ToString(file File) string {
+const (
+	timeColumnWidth    = 19
+	sizeColumnWidth    = 10
+	percentColumnWidth = 6
+	graphWidth         = 10
+	countColumnWidth   = 8
+	columnGap          = "  "
+
+	// Columns are dropped until the path gets at least this many cells
+	minPathWidth = 24
+)
+
+// Order the columns are dropped in when the row is too narrow: modified time, graph, child count, then size
+var columnDropOrder = []int{0, 2, 3, 1}
+
+// ToString lays the file out in fixed width columns, squeezing the path so the whole row fits in width cells.
+// dir is the folder being displayed and total its combined size, used for the relative path and the graph column.
+// On narrow rows the other columns are dropped so the path is never squeezed out
+func ToString(file File, width int, dir string, total int64) string {
+	count := ""
 	if file.Dir {
-		return fmt.Sprintf("%s    %s -> %s (#%d)",
-			file.LastModified,
-			file.Path,
-			utils.FormatSize(uint64(file.Size), true),
-			file.Children)
+		count = fmt.Sprintf("#%d", file.Children)
+	}
+	columns := []string{
+		padRight(file.LastModified, timeColumnWidth),
+		padLeft(utils.FormatSize(uint64(file.Size), true), sizeColumnWidth),
+		graph(file.Size, total),
+		padLeft(count, countColumnWidth),
+	}
+	prefix := joinColumns(columns)
+	for _, i := range columnDropOrder {
+		if width-textWidth(prefix) >= minPathWidth {
+			break
+		}
+		columns[i] = ""
+		prefix = joinColumns(columns)
+	}
+	return prefix + truncateMiddle(relativePath(dir, file.Path), width-textWidth(prefix))
+}
+
+// joinColumns puts the gap after each column that hasn't been dropped
+func joinColumns(columns []string) string {
+	var b strings.Builder
+	for _, column := range columns {
+		if column == "" {
+			continue
+		}
+		b.WriteString(column)
+		b.WriteString(columnGap)
+	}
+	return b.String()
+}
+
+// graph renders the share of total taken up by size as percent text and a proportional bar, like ncdu
+func graph(size int64, total int64) string {
+	if total <= 0 || size <= 0 {
+		return strings.Repeat(" ", percentColumnWidth+1+graphWidth+2)
+	}
+	ratio := float64(size) / float64(total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(math.Round(ratio * graphWidth))
+	return padLeft(fmt.Sprintf("%.1f%%", ratio*100), percentColumnWidth) + " [" +
+		strings.Repeat("#", filled) + strings.Repeat(" ", graphWidth-filled) + "]"
+}
+
+func relativePath(dir string, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// totalSize is the combined size of everything in the folder, skipping the relative navigation entry
+func totalSize(dir string, files []File) int64 {
+	var total int64
+	for _, file := range files {
+		if relativePath(dir, file.Path) == ".." {
+			continue
+		}
+		total += file.Size
+	}
+	return total
+}
+
+func makeFile(dir string, info os.FileInfo) File {
+	filename := filepath.Join(dir, info.Name())
+	return File{
+		Path:         filename,
+		Size:         files.GetSize(dir, info),
+		LastModified: files.PrintTime(info),
+		ModTime:      info.ModTime(),
+		Dir:          info.IsDir(),
+		Children:     files.CountChildren(filename),
 	}
-	return fmt.Sprintf("%s    %s -> %s",
-		file.LastModified,
-		file.Path,
-		utils.FormatSize(uint64(file.Size), true))
 }
 
 func makeRelativeFile(path string, relative string) File {
@@ -42,6 +131,7 @@ func makeRelativeFile(path string, relative string) File {
 		Path:         relativePath,
 		Size:         0,
 		LastModified: files.PrintTime(info),
+		ModTime:      info.ModTime(),
 		Dir:          info.IsDir(),
 		Children:     files.CountChildren(relativePath),
 	}
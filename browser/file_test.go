@@ -0,0 +1,47 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToStringKeepsThePath(t *testing.T) {
+	file := File{
+		Path:         "/data/holiday-photos",
+		Size:         4000000,
+		Dir:          true,
+		LastModified: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC).Format("2006-01-02 15:04:05"),
+		Children:     12,
+	}
+	tests := []struct {
+		width   int
+		columns []string
+		dropped []string
+	}{
+		{width: 120, columns: []string{"2021-03-04", "4.0 MB", "100.0%", "#12"}},
+		{width: 80, columns: []string{"4.0 MB", "100.0%", "#12"}, dropped: []string{"2021-03-04"}},
+		{width: 50, columns: []string{"4.0 MB", "#12"}, dropped: []string{"2021-03-04", "100.0%"}},
+		{width: 40, columns: []string{"4.0 MB"}, dropped: []string{"2021-03-04", "100.0%", "#12"}},
+		{width: 20, dropped: []string{"2021-03-04", "4.0 MB", "100.0%", "#12"}},
+	}
+	for _, test := range tests {
+		row := ToString(file, test.width, "/data", 4000000)
+		if w := textWidth(row); w > test.width {
+			t.Errorf("width %d: row is %d cells wide: %q", test.width, w, row)
+		}
+		if !strings.Contains(row, "holiday") {
+			t.Errorf("width %d: path is missing: %q", test.width, row)
+		}
+		for _, column := range test.columns {
+			if !strings.Contains(row, column) {
+				t.Errorf("width %d: %q is missing: %q", test.width, column, row)
+			}
+		}
+		for _, column := range test.dropped {
+			if strings.Contains(row, column) {
+				t.Errorf("width %d: %q should have been dropped: %q", test.width, column, row)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package browser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kamackay/all/config"
+)
+
+// visit records the path in the history, dropping anything that was ahead of the current position
+func (b *Browser) visit(path string) {
+	if b.historyIndex < len(b.history) && b.history[b.historyIndex] == path {
+		return
+	}
+	if len(b.history) > 0 {
+		b.history = b.history[:b.historyIndex+1]
+	}
+	b.history = append(b.history, path)
+	b.historyIndex = len(b.history) - 1
+}
+
+func (b *Browser) back() {
+	if b.historyIndex > 0 {
+		b.historyIndex--
+		b.loadPath(b.history[b.historyIndex])
+	}
+}
+
+func (b *Browser) forward() {
+	if b.historyIndex < len(b.history)-1 {
+		b.historyIndex++
+		b.loadPath(b.history[b.historyIndex])
+	}
+}
+
+// jump goes to any path, opening the preview if it's a file
+func (b *Browser) jump(path string) {
+	path, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		b.reportError(err)
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		b.reportError(err)
+		return
+	}
+	if info.IsDir() {
+		b.setPath(path)
+		return
+	}
+	b.setPath(filepath.Dir(path))
+	b.preview = NewPreview(path)
+}
+
+func (b *Browser) askJump() {
+	b.ask(&prompt{
+		Label:    "Go to",
+		Input:    b.path + string(filepath.Separator),
+		Complete: completePath,
+		Submit:   b.jump,
+	})
+}
+
+func (b *Browser) askMark() {
+	b.ask(&prompt{
+		Label:  "Mark current folder as",
+		Single: true,
+		Submit: func(letter string) {
+			b.bookmarks[letter] = b.path
+			b.reportError(config.SaveBookmarks(b.bookmarks))
+		},
+	})
+}
+
+func (b *Browser) askBookmark() {
+	b.ask(&prompt{
+		Label:  fmt.Sprintf("Jump to bookmark (%s)", b.bookmarkList()),
+		Single: true,
+		Submit: func(letter string) {
+			if path, ok := b.bookmarks[letter]; ok {
+				b.jump(path)
+			} else {
+				b.notify(fmt.Sprintf("No bookmark '%s'", letter))
+			}
+		},
+	})
+}
+
+func (b *Browser) bookmarkList() string {
+	letters := make([]string, 0, len(b.bookmarks))
+	for letter := range b.bookmarks {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
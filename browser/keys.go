@@ -0,0 +1,259 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+type action string
+
+const (
+	actionUp                action = "up"
+	actionDown              action = "down"
+	actionPageUp            action = "page-up"
+	actionPageDown          action = "page-down"
+	actionTop               action = "top"
+	actionBottom            action = "bottom"
+	actionParent            action = "parent"
+	actionSelect            action = "select"
+	actionClose             action = "close"
+	actionBack              action = "back"
+	actionForward           action = "forward"
+	actionHome              action = "home"
+	actionGoTo              action = "goto"
+	actionMark              action = "mark"
+	actionBookmark          action = "bookmark"
+	actionSort              action = "sort"
+	actionReverse           action = "reverse"
+	actionRefresh           action = "refresh"
+	actionAutoUpdate        action = "auto-update"
+	actionAutoUpdateSubtree action = "auto-update-subtree"
+	actionOpen              action = "open"
+	actionEdit              action = "edit"
+	actionPage              action = "page"
+	actionShell             action = "shell"
+	actionDelete            action = "delete"
+	actionRename            action = "rename"
+	actionMkdir             action = "mkdir"
+	actionChmod             action = "chmod"
+	actionCopy              action = "copy"
+	actionCut               action = "cut"
+	actionPaste             action = "paste"
+	actionHex               action = "hex"
+	actionCompareHash       action = "compare-hash"
+	actionNextDifference    action = "next-difference"
+	actionPrevDifference    action = "previous-difference"
+	actionHelp              action = "help"
+	actionQuit              action = "quit"
+)
+
+// selectionActions need an entry in the list to act on
+var selectionActions = map[action]bool{
+	actionSelect: true,
+	actionDelete: true,
+	actionRename: true,
+	actionChmod:  true,
+	actionCopy:   true,
+	actionCut:    true,
+	actionOpen:   true,
+	actionEdit:   true,
+	actionPage:   true,
+}
+
+type binding struct {
+	Action      action
+	Description string
+	Keys        []string
+}
+
+// defaultBindings is the built in keymap, in the order it's listed in the help overlay
+var defaultBindings = []binding{
+	{actionUp, "Move up", []string{"Up", "k"}},
+	{actionDown, "Move down", []string{"Down", "j"}},
+	{actionPageUp, "Move one screen up", []string{"PgUp"}},
+	{actionPageDown, "Move one screen down", []string{"PgDn"}},
+	{actionTop, "Go to top of list", []string{"Home", "["}},
+	{actionBottom, "Go to bottom of list", []string{"End", "]"}},
+	{actionParent, "Go up a folder, or close the preview", []string{"Left"}},
+	{actionSelect, "Open folder or preview file", []string{"Right", "Enter"}},
+	{actionClose, "Close the preview", []string{"Esc"}},
+	{actionBack, "Go back", []string{"Alt+Left", "h"}},
+	{actionForward, "Go forward", []string{"Alt+Right", "l"}},
+	{actionHome, "Go to home folder", []string{"~"}},
+	{actionGoTo, "Type a path to go to", []string{"g"}},
+	{actionMark, "Bookmark current folder", []string{"m"}},
+	{actionBookmark, "Jump to bookmark", []string{"'"}},
+	{actionSort, "Cycle sort mode", []string{"s"}},
+	{actionReverse, "Reverse sort order", []string{"S"}},
+	{actionRefresh, "Refresh", []string{"r"}},
+	{actionAutoUpdate, "Toggle auto update", []string{"a"}},
+	{actionAutoUpdateSubtree, "Toggle auto update including subfolders", []string{"A"}},
+	{actionOpen, "Open with open_with command or default application", []string{"o"}},
+	{actionEdit, "Edit in $EDITOR", []string{"e"}},
+	{actionPage, "View in $PAGER", []string{"p"}},
+	{actionShell, "Run a shell in the current folder", []string{"!"}},
+	{actionDelete, "Delete", []string{"Delete", "Ctrl+D"}},
+	{actionRename, "Rename", []string{"R"}},
+	{actionMkdir, "New folder", []string{"+"}},
+	{actionChmod, "Change permissions", []string{"P"}},
+	{actionCopy, "Copy", []string{"c"}},
+	{actionCut, "Cut", []string{"X"}},
+	{actionPaste, "Paste into current folder", []string{"v"}},
+	{actionHex, "Toggle hex view in the preview", []string{"x"}},
+	{actionCompareHash, "Compare mode: toggle comparing file contents instead of modified times", []string{"#"}},
+	{actionNextDifference, "Compare mode: go to the next difference", []string{"n"}},
+	{actionPrevDifference, "Compare mode: go to the previous difference", []string{"N"}},
+	{actionHelp, "Show this help", []string{"?"}},
+	{actionQuit, "Quit", []string{"q"}},
+}
+
+type keyID struct {
+	Key termbox.Key
+	Ch  rune
+	Mod termbox.Modifier
+}
+
+type keymap struct {
+	actions  map[keyID]action
+	bindings []binding
+}
+
+var namedKeys = map[string]termbox.Key{
+	"up":        termbox.KeyArrowUp,
+	"down":      termbox.KeyArrowDown,
+	"left":      termbox.KeyArrowLeft,
+	"right":     termbox.KeyArrowRight,
+	"pgup":      termbox.KeyPgup,
+	"pgdn":      termbox.KeyPgdn,
+	"home":      termbox.KeyHome,
+	"end":       termbox.KeyEnd,
+	"enter":     termbox.KeyEnter,
+	"esc":       termbox.KeyEsc,
+	"tab":       termbox.KeyTab,
+	"space":     termbox.KeySpace,
+	"delete":    termbox.KeyDelete,
+	"insert":    termbox.KeyInsert,
+	"backspace": termbox.KeyBackspace2,
+	"f1":        termbox.KeyF1,
+	"f2":        termbox.KeyF2,
+	"f3":        termbox.KeyF3,
+	"f4":        termbox.KeyF4,
+	"f5":        termbox.KeyF5,
+	"f6":        termbox.KeyF6,
+	"f7":        termbox.KeyF7,
+	"f8":        termbox.KeyF8,
+	"f9":        termbox.KeyF9,
+	"f10":       termbox.KeyF10,
+	"f11":       termbox.KeyF11,
+	"f12":       termbox.KeyF12,
+}
+
+// parseKey turns a key name like "j", "PgUp", "Ctrl+D" or "Alt+Left" into what termbox reports for it
+func parseKey(name string) (keyID, error) {
+	var id keyID
+	rest := name
+	if len(rest) > 4 && strings.EqualFold(rest[:4], "alt+") {
+		id.Mod = termbox.ModAlt
+		rest = rest[4:]
+	}
+	if utf8.RuneCountInString(rest) == 1 {
+		id.Ch, _ = utf8.DecodeRuneInString(rest)
+		return id, nil
+	}
+	if len(rest) == 6 && strings.EqualFold(rest[:5], "ctrl+") {
+		letter := strings.ToLower(rest)[5]
+		if letter >= 'a' && letter <= 'z' {
+			id.Key = termbox.KeyCtrlA + termbox.Key(letter-'a')
+			return id, nil
+		}
+	}
+	if key, ok := namedKeys[strings.ToLower(rest)]; ok {
+		id.Key = key
+		return id, nil
+	}
+	return id, fmt.Errorf("unknown key %q", name)
+}
+
+// newKeymap builds the keymap from the defaults, replacing the keys of any action listed in overrides.
+// Configured keys are bound first, so a key moved to another action is taken away from its default one
+func newKeymap(overrides map[string][]string) (*keymap, []error) {
+	errs := make([]error, 0)
+	known := make(map[action]bool)
+	for _, b := range defaultBindings {
+		known[b.Action] = true
+	}
+	for name := range overrides {
+		if !known[action(name)] {
+			errs = append(errs, fmt.Errorf("unknown action %q in keys config", name))
+		}
+	}
+	k := &keymap{actions: make(map[keyID]action), bindings: make([]binding, len(defaultBindings))}
+	copy(k.bindings, defaultBindings)
+	configured := make([]int, 0, len(overrides))
+	defaults := make([]int, 0, len(k.bindings))
+	for i, b := range k.bindings {
+		if keys, ok := overrides[string(b.Action)]; ok {
+			k.bindings[i].Keys = keys
+			configured = append(configured, i)
+		} else {
+			defaults = append(defaults, i)
+		}
+	}
+	for _, i := range append(configured, defaults...) {
+		b := &k.bindings[i]
+		valid := make([]string, 0, len(b.Keys))
+		for _, name := range b.Keys {
+			id, err := parseKey(name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if other, ok := k.actions[id]; ok {
+				if other != b.Action {
+					errs = append(errs, fmt.Errorf("key %q is bound to both %s and %s in keys config", name, other, b.Action))
+				}
+				continue
+			}
+			k.actions[id] = b.Action
+			valid = append(valid, name)
+		}
+		b.Keys = valid
+	}
+	return k, errs
+}
+
+// KeyEvent is the event termbox sends for a key name like "j", "Enter" or "Ctrl+D", the same names used in config.toml
+func KeyEvent(name string) (termbox.Event, error) {
+	id, err := parseKey(name)
+	if err != nil {
+		return termbox.Event{}, err
+	}
+	return termbox.Event{Type: termbox.EventKey, Key: id.Key, Ch: id.Ch, Mod: id.Mod}, nil
+}
+
+func (k *keymap) lookup(e termbox.Event) (action, bool) {
+	id := keyID{Key: e.Key, Ch: e.Ch, Mod: e.Mod}
+	if e.Ch != 0 {
+		id.Key = 0
+	}
+	a, ok := k.actions[id]
+	return a, ok
+}
+
+// help lists every action with its keys, one per line
+func (k *keymap) help() []string {
+	width := 0
+	for _, b := range k.bindings {
+		if w := textWidth(strings.Join(b.Keys, ", ")); w > width {
+			width = w
+		}
+	}
+	lines := make([]string, 0, len(k.bindings))
+	for _, b := range k.bindings {
+		lines = append(lines, padRight(strings.Join(b.Keys, ", "), width)+"  "+b.Description)
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeymapHasNoDuplicates(t *testing.T) {
+	if _, errs := newKeymap(nil); len(errs) > 0 {
+		t.Fatalf("default keymap has errors: %v", errs)
+	}
+}
+
+func TestKeymapDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string][]string
+		key       string
+		want      action
+		errs      int
+	}{
+		{name: "moved onto a default", overrides: map[string][]string{"sort": {"j"}}, key: "j", want: actionSort, errs: 1},
+		{name: "two configured actions", overrides: map[string][]string{"sort": {"z"}, "reverse": {"z"}}, key: "z", want: actionSort, errs: 1},
+		{name: "listed twice for one action", overrides: map[string][]string{"sort": {"z", "z"}}, key: "z", want: actionSort},
+		{name: "no clash", overrides: map[string][]string{"sort": {"z"}}, key: "j", want: actionDown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k, errs := newKeymap(test.overrides)
+			if len(errs) != test.errs {
+				t.Errorf("got %d errors, want %d: %v", len(errs), test.errs, errs)
+			}
+			for _, err := range errs {
+				if !strings.Contains(err.Error(), "bound to both") {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			event, err := KeyEvent(test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a, _ := k.lookup(event); a != test.want {
+				t.Errorf("%s is bound to %q, want %q", test.key, a, test.want)
+			}
+			for _, b := range k.bindings {
+				if b.Action == test.want {
+					continue
+				}
+				for _, name := range b.Keys {
+					if name == test.key {
+						t.Errorf("help still lists %s for %s", test.key, b.Action)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package browser
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+type Cell struct {
+	Ch     rune
+	Fg, Bg termbox.Attribute
+}
+
+// MemoryScreen is a Screen that keeps the grid in memory and takes its input from Inject, for running the
+// browser headless
+type MemoryScreen struct {
+	mutex         sync.Mutex
+	width, height int
+	back, front   [][]Cell
+	cursorX       int
+	cursorY       int
+	events        chan termbox.Event
+	interrupts    chan struct{}
+	flushes       int
+	pendingEvents int
+}
+
+func NewMemoryScreen(width, height int) *MemoryScreen {
+	s := &MemoryScreen{
+		events:     make(chan termbox.Event, 256),
+		interrupts: make(chan struct{}),
+	}
+	s.resize(width, height)
+	return s
+}
+
+func makeGrid(width, height int) [][]Cell {
+	grid := make([][]Cell, height)
+	for y := range grid {
+		grid[y] = make([]Cell, width)
+		for x := range grid[y] {
+			grid[y][x] = Cell{Ch: ' '}
+		}
+	}
+	return grid
+}
+
+func (s *MemoryScreen) resize(width, height int) {
+	s.width = width
+	s.height = height
+	s.back = makeGrid(width, height)
+	s.front = makeGrid(width, height)
+}
+
+func (s *MemoryScreen) Init() error {
+	return nil
+}
+
+func (s *MemoryScreen) Close() {}
+
+func (s *MemoryScreen) SetInputMode(mode termbox.InputMode) {}
+
+func (s *MemoryScreen) Size() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.width, s.height
+}
+
+func (s *MemoryScreen) Clear(fg, bg termbox.Attribute) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for y := range s.back {
+		for x := range s.back[y] {
+			s.back[y][x] = Cell{Ch: ' ', Fg: fg, Bg: bg}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if x < 0 || y < 0 || y >= s.height || x >= s.width {
+		return
+	}
+	s.back[y][x] = Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+func (s *MemoryScreen) SetCursor(x, y int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cursorX, s.cursorY = x, y
+}
+
+func (s *MemoryScreen) HideCursor() {
+	s.SetCursor(-1, -1)
+}
+
+// Flush publishes the back buffer, like the terminal only the flushed grid is visible
+func (s *MemoryScreen) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for y := range s.back {
+		copy(s.front[y], s.back[y])
+	}
+	s.flushes++
+	return nil
+}
+
+func (s *MemoryScreen) PollEvent() termbox.Event {
+	select {
+	case e := <-s.events:
+		s.mutex.Lock()
+		s.pendingEvents--
+		if e.Type == termbox.EventResize {
+			s.resize(e.Width, e.Height)
+		}
+		s.mutex.Unlock()
+		return e
+	case <-s.interrupts:
+		return termbox.Event{Type: termbox.EventInterrupt}
+	}
+}
+
+func (s *MemoryScreen) Interrupt() {
+	s.interrupts <- struct{}{}
+}
+
+// Inject queues an input event for the browser to read
+func (s *MemoryScreen) Inject(e termbox.Event) {
+	s.mutex.Lock()
+	s.pendingEvents++
+	s.mutex.Unlock()
+	s.events <- e
+}
+
+// Resize changes the size of the screen, the same way resizing a terminal window would
+func (s *MemoryScreen) Resize(width, height int) {
+	s.Inject(termbox.Event{Type: termbox.EventResize, Width: width, Height: height})
+}
+
+// Pending is the number of injected events that haven't been read yet
+func (s *MemoryScreen) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pendingEvents
+}
+
+// Flushes counts the frames drawn so far
+func (s *MemoryScreen) Flushes() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.flushes
+}
+
+func (s *MemoryScreen) Cell(x, y int) Cell {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if x < 0 || y < 0 || y >= s.height || x >= s.width {
+		return Cell{}
+	}
+	return s.front[y][x]
+}
+
+// Cursor is the position of the text cursor, or -1, -1 when it's hidden
+func (s *MemoryScreen) Cursor() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.cursorX, s.cursorY
+}
+
+// Lines is the text of the last flushed frame, one string per row with trailing spaces trimmed
+func (s *MemoryScreen) Lines() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	lines := make([]string, len(s.front))
+	for y, row := range s.front {
+		var line strings.Builder
+		for x := 0; x < len(row); x++ {
+			line.WriteRune(row[x].Ch)
+			if runewidth.RuneWidth(row[x].Ch) == 2 {
+				// The terminal draws wide runes over the next cell too
+				x++
+			}
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+// String is the whole last flushed frame
+func (s *MemoryScreen) String() string {
+	return strings.Join(s.Lines(), "\n")
+}
@@ -0,0 +1,215 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/l"
+	"github.com/kamackay/all/model"
+	"github.com/kamackay/all/utils"
+	"github.com/nsf/termbox-go"
+)
+
+// Only redraw copy progress this often, io.Copy reports every 32kB
+const progressInterval = 100 * time.Millisecond
+
+// clipboard holds the entry picked with copy or cut until it's pasted
+type clipboard struct {
+	Path string
+	Cut  bool
+}
+
+func (b *Browser) confirm(message string, action func()) {
+	b.confirmations = append(b.confirmations, model.Confirmation{
+		Message: message,
+		Action:  action,
+	})
+}
+
+func (b *Browser) askRename() {
+	path := b.getCurrentFile().Path
+	if b.isRelativeEntry(path) {
+		return
+	}
+	b.ask(&prompt{
+		Label: "Rename to",
+		Input: filepath.Base(path),
+		Submit: func(name string) {
+			if name == "" || name == filepath.Base(path) {
+				return
+			}
+			target := filepath.Join(filepath.Dir(path), name)
+			message := fmt.Sprintf("Rename %s to %s?", path, target)
+			if _, err := os.Lstat(target); err == nil {
+				message = fmt.Sprintf("Rename %s to %s, replacing what's already there?", path, target)
+			}
+			b.confirm(message, func() {
+				l.Print(fmt.Sprintf("Renaming %s to %s", path, target))
+				b.reportError(os.Rename(path, target))
+				b.getFiles(false)
+			})
+		},
+	})
+}
+
+func (b *Browser) askMkdir() {
+	dir := b.path
+	b.ask(&prompt{
+		Label: "New folder",
+		Submit: func(name string) {
+			if name == "" {
+				return
+			}
+			b.reportError(os.MkdirAll(filepath.Join(dir, name), 0755))
+			b.getFiles(false)
+		},
+	})
+}
+
+func (b *Browser) askChmod() {
+	path := b.getCurrentFile().Path
+	info, err := os.Lstat(path)
+	if err != nil {
+		b.reportError(err)
+		return
+	}
+	b.ask(&prompt{
+		Label: fmt.Sprintf("Permissions for %s (octal)", filepath.Base(path)),
+		Input: strconv.FormatUint(uint64(info.Mode().Perm()), 8),
+		Submit: func(input string) {
+			mode, err := strconv.ParseUint(input, 8, 32)
+			if err != nil || mode > 0777 {
+				b.notify(fmt.Sprintf("Invalid permissions %q", input))
+				return
+			}
+			perm := os.FileMode(mode)
+			b.confirm(fmt.Sprintf("Change permissions of %s from %s to %s?", path, info.Mode().Perm(), perm), func() {
+				b.reportError(os.Chmod(path, perm))
+				b.getFiles(false)
+			})
+		},
+	})
+}
+
+func (b *Browser) yank(cut bool) {
+	path := b.getCurrentFile().Path
+	if b.isRelativeEntry(path) {
+		return
+	}
+	b.clipboard = &clipboard{Path: path, Cut: cut}
+}
+
+// paste copies or moves the clipboard entry into the current folder
+func (b *Browser) paste() {
+	c := b.clipboard
+	if c == nil {
+		return
+	}
+	dir := b.path
+	target := filepath.Join(dir, filepath.Base(c.Path))
+	if c.Cut && target == c.Path {
+		// Already here
+		return
+	}
+	if dir == c.Path || strings.HasPrefix(dir, c.Path+string(filepath.Separator)) {
+		b.notify(fmt.Sprintf("Can't paste %s inside of itself", c.Path))
+		return
+	}
+	if strings.HasPrefix(c.Path, target+string(filepath.Separator)) {
+		// Replacing the target would delete what's being pasted along with it
+		b.notify(fmt.Sprintf("Can't paste %s over %s, which holds it", c.Path, target))
+		return
+	}
+	if !c.Cut && target == c.Path {
+		target = uniqueName(target)
+	}
+	verb := "Copy"
+	if c.Cut {
+		verb = "Move"
+	}
+	message := fmt.Sprintf("%s %s to %s?", verb, c.Path, target)
+	if _, err := os.Lstat(target); err == nil {
+		message = fmt.Sprintf("%s %s to %s, replacing what's already there?", verb, c.Path, target)
+	}
+	b.confirm(message, func() {
+		if c.Cut {
+			b.clipboard = nil
+		}
+		b.transfer(verb, c.Path, target, c.Cut)
+	})
+}
+
+// transfer copies or moves in the background, showing progress at the bottom of the screen.
+// Adding up the size and clearing the target can take a while for big folders, so they happen in the background too
+func (b *Browser) transfer(verb string, src string, dst string, move bool) {
+	op := &model.Operation{Label: fmt.Sprintf("%s %s", verb, filepath.Base(src))}
+	b.operation = op
+	go func() {
+		total := files.TotalSize(src)
+		b.send(context.Background(), progressMsg{op: op, total: total})
+		if _, err := os.Lstat(dst); err == nil {
+			// Confirmed replacing whatever was there
+			if err := os.RemoveAll(dst); err != nil {
+				b.send(context.Background(), operationDoneMsg{op: op, err: err})
+				return
+			}
+		}
+		lastDraw := time.Now()
+		progress := func(copied int64) {
+			if time.Since(lastDraw) > progressInterval {
+				lastDraw = time.Now()
+				b.trySend(progressMsg{op: op, done: copied, total: total})
+			}
+		}
+		l.Print(fmt.Sprintf("%s %s to %s", verb, src, dst))
+		var err error
+		if move {
+			err = files.Move(src, dst, progress)
+		} else {
+			err = files.Copy(src, dst, progress)
+		}
+		b.send(context.Background(), operationDoneMsg{op: op, err: err})
+	}()
+}
+
+// uniqueName finds a free name next to path, like "file copy.txt" or "file copy 2.txt"
+func uniqueName(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := fmt.Sprintf("%s copy%s", base, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s copy %d%s", base, i, ext)
+	}
+}
+
+// isRelativeEntry checks for the ".." entry at the top of the list, which can't be renamed or copied
+func (b *Browser) isRelativeEntry(path string) bool {
+	return relativePath(b.path, path) == ".."
+}
+
+func (b *Browser) renderOperation() {
+	op := b.operation
+	// Just above the status bar
+	y := b.Height - 2
+	if op.Total == 0 && op.Done == 0 {
+		b.drawStringAt(padRight(op.Label+": working out the size"+ellipsis, b.Width), 0, y, b.Width, termbox.ColorYellow, black)
+		return
+	}
+	percent := 100.0
+	if op.Total > 0 {
+		percent = float64(op.Done) / float64(op.Total) * 100
+	}
+	text := fmt.Sprintf("%s: %s of %s %s", op.Label,
+		utils.HumanizeBytes(uint64(op.Done)), utils.HumanizeBytes(uint64(op.Total)), graph(op.Done, op.Total))
+	b.drawStringAt(padRight(fmt.Sprintf("%s (%.0f%%)", text, percent), b.Width), 0, y, b.Width, termbox.ColorYellow, black)
+}
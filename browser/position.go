@@ -0,0 +1,57 @@
+package browser
+
+import "path/filepath"
+
+// position is where the cursor was in a folder, kept for the session so coming back puts you where you left off
+type position struct {
+	Selected string
+	Offset   int
+}
+
+func (b *Browser) rememberPosition() {
+	if b.filesPath != b.path {
+		// The list is still showing another folder
+		return
+	}
+	if b.SelectedLine < 0 || b.SelectedLine >= len(b.Files) {
+		return
+	}
+	b.positions[b.path] = position{
+		Selected: b.Files[b.SelectedLine].Path,
+		Offset:   b.offset,
+	}
+}
+
+// leave saves the position in the current folder before moving to path. When moving up a level
+// the folder being left is highlighted in its parent
+func (b *Browser) leave(path string) {
+	b.rememberPosition()
+	if filepath.Dir(b.path) == path && b.path != path {
+		p := b.positions[path]
+		p.Selected = b.path
+		b.positions[path] = p
+	}
+}
+
+// restorePosition puts the cursor back on the entry that was selected last time the folder was shown
+func (b *Browser) restorePosition() {
+	p, ok := b.positions[b.path]
+	if !ok {
+		b.offset = 0
+		b.setIndex(0)
+		return
+	}
+	b.offset = p.Offset
+	for i, file := range b.Files {
+		if file.Path == p.Selected {
+			b.setIndex(i)
+			return
+		}
+	}
+	b.setIndex(0)
+}
+
+// up goes to the parent folder
+func (b *Browser) up() {
+	b.setPath(filepath.Dir(b.path))
+}
@@ -0,0 +1,225 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/utils"
+	"github.com/nsf/termbox-go"
+)
+
+const (
+	// Only this much of a file is read into the preview pane
+	previewLimit = 256 << 10
+	// Number of bytes checked when deciding whether a file is binary
+	sniffLength = 8000
+	// Number of lines at the top of the pane taken up by the file's metadata
+	previewHeaderLines = 3
+)
+
+type Preview struct {
+	Path   string
+	Info   os.FileInfo
+	Owner  string
+	Binary bool
+	Hex    bool
+	Scroll int
+	Err    error
+	syntax *syntax
+	data   []byte
+	text   []string
+	dump   []string
+}
+
+type syntax struct {
+	Comment string
+}
+
+// Line comment markers by extension, used to color comments and strings in the preview
+var syntaxes = map[string]*syntax{
+	".go":   {Comment: "//"},
+	".c":    {Comment: "//"},
+	".h":    {Comment: "//"},
+	".cpp":  {Comment: "//"},
+	".java": {Comment: "//"},
+	".js":   {Comment: "//"},
+	".ts":   {Comment: "//"},
+	".rs":   {Comment: "//"},
+	".kt":   {Comment: "//"},
+	".py":   {Comment: "#"},
+	".sh":   {Comment: "#"},
+	".rb":   {Comment: "#"},
+	".yml":  {Comment: "#"},
+	".yaml": {Comment: "#"},
+	".toml": {Comment: "#"},
+	".sql":  {Comment: "--"},
+	".lua":  {Comment: "--"},
+}
+
+func NewPreview(path string) *Preview {
+	p := &Preview{Path: path, syntax: syntaxes[strings.ToLower(filepath.Ext(path))]}
+	p.Info, p.Err = os.Stat(path)
+	if p.Err != nil {
+		return p
+	}
+	p.Owner = files.Owner(p.Info)
+	data, err := files.ReadHead(path, previewLimit)
+	if err != nil {
+		p.Err = err
+		return p
+	}
+	p.data = data
+	p.Binary = isBinary(data)
+	p.Hex = p.Binary
+	if !p.Binary {
+		p.text = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	}
+	return p
+}
+
+// isBinary guesses whether the data is text by looking for NUL bytes and invalid UTF-8
+func isBinary(data []byte) bool {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	// Allow for a multi-byte rune cut off at the end of the sample
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return !utf8.Valid(data)
+}
+
+func (p *Preview) lines() []string {
+	if !p.Hex {
+		return p.text
+	}
+	if p.dump == nil {
+		// Only build the dump once it's asked for, it's about 4 times the size of the data
+		p.dump = strings.Split(strings.TrimRight(hex.Dump(p.data), "\n"), "\n")
+	}
+	return p.dump
+}
+
+// ToggleHex switches between the text and hex views, binary files can only be shown as hex
+func (p *Preview) ToggleHex() {
+	if p.Binary {
+		return
+	}
+	p.Hex = !p.Hex
+	p.Scroll = 0
+}
+
+func (p *Preview) ScrollTo(line int, height int) {
+	if max := len(p.lines()) - height; line > max {
+		line = max
+	}
+	if line < 0 {
+		line = 0
+	}
+	p.Scroll = line
+}
+
+func (p *Preview) metadata() string {
+	if p.Info == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s  %s  %s  %s", p.Info.Mode(), p.Owner, files.PrintTime(p.Info),
+		utils.FormatSize(uint64(p.Info.Size()), true))
+}
+
+func (p *Preview) modeName() string {
+	switch {
+	case p.Binary:
+		return "binary, hex"
+	case p.Hex:
+		return "text, hex"
+	default:
+		return "text"
+	}
+}
+
+func (b *Browser) renderPreview(p *Preview, x int, width int) {
+	top := 1
+	b.drawStringAt(truncateMiddle(p.Path, width), x, top, width, termbox.ColorLightMagenta, black)
+	if p.Err != nil {
+		b.drawStringAt(fmt.Sprintf("Error Reading file: %+v", p.Err), x, top+1, width, termbox.ColorRed, black)
+		return
+	}
+	b.drawStringAt(p.metadata(), x, top+1, width, termbox.ColorCyan, black)
+	lines := p.lines()
+	height := b.previewHeight()
+	b.drawStringAt(fmt.Sprintf("[%s] lines %d-%d of %d", p.modeName(), p.Scroll+1,
+		utils.Min(p.Scroll+height, len(lines)), len(lines)), x, top+2, width, termbox.ColorYellow, black)
+
+	numberWidth := len(fmt.Sprintf("%d", len(lines)))
+	for i := 0; i < height && p.Scroll+i < len(lines); i++ {
+		y := top + previewHeaderLines + i
+		line := lines[p.Scroll+i]
+		if p.Hex {
+			b.drawStringAt(line, x, y, width, green, black)
+			continue
+		}
+		gutter := fmt.Sprintf("%*d | ", numberWidth, p.Scroll+i+1)
+		used := b.drawStringAt(gutter, x, y, width, termbox.ColorDarkGray, black)
+		for _, s := range highlight(line, p.syntax) {
+			used += b.drawStringAt(s.text, x+used, y, width-used, s.fg, black)
+		}
+	}
+}
+
+// previewHeight is the number of content lines that fit in the preview pane
+func (b *Browser) previewHeight() int {
+	if h := b.pageSize() - previewHeaderLines; h > 0 {
+		return h
+	}
+	return 1
+}
+
+type segment struct {
+	text string
+	fg   termbox.Attribute
+}
+
+// highlight splits a line of source into colored segments for strings and line comments
+func highlight(line string, s *syntax) []segment {
+	if s == nil {
+		return []segment{{text: line, fg: green}}
+	}
+	segments := make([]segment, 0)
+	start := 0
+	var quote rune
+	for i, r := range line {
+		if quote != 0 {
+			if r == quote && (i == 0 || line[i-1] != '\\') {
+				segments = append(segments, segment{text: line[start : i+1], fg: termbox.ColorYellow})
+				start = i + 1
+				quote = 0
+			}
+			continue
+		}
+		if strings.HasPrefix(line[i:], s.Comment) {
+			segments = append(segments, segment{text: line[start:i], fg: green},
+				segment{text: line[i:], fg: termbox.ColorDarkGray})
+			return segments
+		}
+		if r == '"' || r == '\'' || r == '`' {
+			segments = append(segments, segment{text: line[start:i], fg: green})
+			start = i
+			quote = r
+		}
+	}
+	fg := green
+	if quote != 0 {
+		fg = termbox.ColorYellow
+	}
+	return append(segments, segment{text: line[start:], fg: fg})
+}
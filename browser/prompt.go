@@ -0,0 +1,129 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// prompt is an inline text input shown on the bottom line of the browser
+type prompt struct {
+	Label string
+	Input string
+	// Single prompts submit as soon as one character is typed, used for things like picking a bookmark letter
+	Single   bool
+	Complete func(input string) string
+	Submit   func(input string)
+}
+
+func (b *Browser) ask(p *prompt) {
+	b.prompt = p
+}
+
+// promptKeyPress feeds a key to the open prompt
+func (b *Browser) promptKeyPress(e termbox.Event) {
+	p := b.prompt
+	switch e.Key {
+	case termbox.KeyEsc:
+		b.prompt = nil
+	case termbox.KeyEnter:
+		b.prompt = nil
+		p.Submit(p.Input)
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		if runes := []rune(p.Input); len(runes) > 0 {
+			p.Input = string(runes[:len(runes)-1])
+		}
+	case termbox.KeyTab:
+		if p.Complete != nil {
+			p.Input = p.Complete(p.Input)
+		}
+	case termbox.KeySpace:
+		p.Input += " "
+	default:
+		if e.Ch == 0 {
+			break
+		}
+		if p.Single {
+			b.prompt = nil
+			p.Submit(string(e.Ch))
+			return
+		}
+		p.Input += string(e.Ch)
+	}
+}
+
+func (b *Browser) renderPrompt() {
+	p := b.prompt
+	// Just above the status bar
+	y := b.Height - 2
+	for x := 0; x < b.Width; x++ {
+		b.screen.SetCell(x, y, ' ', black, black)
+	}
+	used := b.drawStringAt(p.Label+": ", 0, y, b.Width, termbox.ColorYellow, black)
+	// Keep the end of the input in view while typing long paths
+	input := p.Input
+	for textWidth(input) >= b.Width-used && len(input) > 0 {
+		input = string([]rune(input)[1:])
+	}
+	used += b.drawStringAt(input, used, y, b.Width-used, termbox.ColorWhite, black)
+	if !p.Single {
+		b.screen.SetCursor(used, y)
+	}
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// completePath fills in as much of the path as is shared by every entry it could refer to
+func completePath(input string) string {
+	if input == "~" {
+		return input + string(filepath.Separator)
+	}
+	expanded := expandHome(input)
+	dir, prefix := filepath.Split(expanded)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return input
+	}
+	matches := make([]os.DirEntry, 0)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix) {
+			matches = append(matches, entry)
+		}
+	}
+	if len(matches) == 0 {
+		return input
+	}
+	common := matches[0].Name()
+	for _, match := range matches[1:] {
+		common = commonPrefix(common, match.Name())
+	}
+	completed := input[:len(input)-len(prefix)] + common
+	if len(matches) == 1 && matches[0].IsDir() {
+		completed += string(filepath.Separator)
+	}
+	return completed
+}
+
+func commonPrefix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	i := 0
+	for i < len(ra) && i < len(rb) && ra[i] == rb[i] {
+		i++
+	}
+	return string(ra[:i])
+}
@@ -0,0 +1,38 @@
+package browser
+
+import "github.com/nsf/termbox-go"
+
+// Screen is everything the browser needs from the terminal, so it can be swapped for an in-memory one in tests
+type Screen interface {
+	Init() error
+	Close()
+	SetInputMode(mode termbox.InputMode)
+	Size() (width, height int)
+	Clear(fg, bg termbox.Attribute) error
+	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	SetCursor(x, y int)
+	HideCursor()
+	Flush() error
+	// PollEvent blocks until there's input, a resize or a call to Interrupt
+	PollEvent() termbox.Event
+	Interrupt()
+}
+
+// termboxScreen draws to the real terminal
+type termboxScreen struct{}
+
+func (termboxScreen) Init() error                         { return termbox.Init() }
+func (termboxScreen) Close()                              { termbox.Close() }
+func (termboxScreen) SetInputMode(mode termbox.InputMode) { termbox.SetInputMode(mode) }
+func (termboxScreen) Size() (int, int)                    { return termbox.Size() }
+func (termboxScreen) Clear(fg, bg termbox.Attribute) error {
+	return termbox.Clear(fg, bg)
+}
+func (termboxScreen) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+func (termboxScreen) SetCursor(x, y int)       { termbox.SetCursor(x, y) }
+func (termboxScreen) HideCursor()              { termbox.HideCursor() }
+func (termboxScreen) Flush() error             { return termbox.Flush() }
+func (termboxScreen) PollEvent() termbox.Event { return termbox.PollEvent() }
+func (termboxScreen) Interrupt()               { termbox.Interrupt() }
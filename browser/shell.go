@@ -0,0 +1,123 @@
+package browser
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamackay/all/l"
+	"github.com/nsf/termbox-go"
+	"github.com/skratchdot/open-golang/open"
+)
+
+// Placeholder in open_with commands replaced by the file's path, if it's left out the path goes at the end
+const pathPlaceholder = "{}"
+
+// suspend hands the terminal over to an external program, restoring the browser when it exits
+func (b *Browser) suspend(cmd *exec.Cmd) {
+	// Get the poll loop out of termbox before closing it, dropping anything typed in the meantime
+	go b.screen.Interrupt()
+	for paused := false; !paused; {
+		select {
+		case <-b.pausedChan:
+			paused = true
+		case msg := <-b.messages:
+			if _, ok := msg.(inputMsg); !ok {
+				b.handle(msg)
+			}
+		}
+	}
+	b.screen.Close()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	l.Print(fmt.Sprintf("Running %s", strings.Join(cmd.Args, " ")))
+	b.reportError(cmd.Run())
+	if err := b.screen.Init(); err != nil {
+		l.Error(err)
+		os.Exit(1)
+	}
+	b.screen.SetInputMode(termbox.InputAlt | termbox.InputMouse)
+	w, h := b.screen.Size()
+	b.setSize(h, w)
+	b.resumeChan <- struct{}{}
+	if b.preview != nil {
+		// The file may have been edited
+		b.preview = NewPreview(b.preview.Path)
+	}
+	b.getFiles(false)
+}
+
+// command builds a command from a line like "mpv --fs {}", with the file path filled in
+func command(line string, path string) *exec.Cmd {
+	fields := strings.Fields(line)
+	replaced := false
+	for i, field := range fields {
+		if strings.Contains(field, pathPlaceholder) {
+			fields[i] = strings.ReplaceAll(field, pathPlaceholder, path)
+			replaced = true
+		}
+	}
+	if !replaced {
+		fields = append(fields, path)
+	}
+	return exec.Command(fields[0], fields[1:]...)
+}
+
+// firstEnv is the value of the first environment variable that's set, or the fallback
+func firstEnv(fallback string, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+	return fallback
+}
+
+func (b *Browser) edit() {
+	path := b.getCurrentFile().Path
+	b.suspend(command(firstEnv("vi", "VISUAL", "EDITOR"), path))
+}
+
+func (b *Browser) page() {
+	current := b.getCurrentFile()
+	if current.Dir {
+		return
+	}
+	b.suspend(command(firstEnv("less", "PAGER"), current.Path))
+}
+
+func (b *Browser) shell() {
+	cmd := exec.Command(firstEnv("/bin/sh", "SHELL"))
+	cmd.Dir = b.path
+	b.suspend(cmd)
+}
+
+// openCurrent opens the entry with the open_with command for its extension, or the desktop default
+func (b *Browser) openCurrent() {
+	path := b.getCurrentFile().Path
+	if line, ok := b.openWith[strings.ToLower(filepath.Ext(path))]; ok {
+		b.suspend(command(line, path))
+		return
+	}
+	b.reportError(open.Run(path))
+}
+
+// normalizeExtensions lowercases the extensions in the open_with table and makes sure they start with a dot.
+// Blank commands are dropped so those extensions open with the desktop default
+func normalizeExtensions(openWith map[string]string) map[string]string {
+	normalized := make(map[string]string, len(openWith))
+	for ext, line := range openWith {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[ext] = line
+	}
+	return normalized
+}
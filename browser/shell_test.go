@@ -0,0 +1,38 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeExtensions(t *testing.T) {
+	got := normalizeExtensions(map[string]string{
+		"MKV":  "mpv --fs {}",
+		".Pdf": "zathura",
+		"txt":  "",
+		".log": "  \t ",
+	})
+	want := map[string]string{
+		".mkv": "mpv --fs {}",
+		".pdf": "zathura",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeExtensions = %v, want %v", got, want)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"mpv --fs {}", []string{"mpv", "--fs", "/tmp/a b.mkv"}},
+		{"zathura", []string{"zathura", "/tmp/a b.mkv"}},
+		{"cp {} {}.bak", []string{"cp", "/tmp/a b.mkv", "/tmp/a b.mkv.bak"}},
+	}
+	for _, test := range tests {
+		if got := command(test.line, "/tmp/a b.mkv").Args; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("command(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package browser
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kamackay/all/model"
+)
+
+// sortFiles orders the list in place, ties are always broken by name so the order is stable between reloads
+func sortFiles(fileList []File, sortType model.SortType, reverse bool) {
+	byName := func(i, j int) int {
+		return strings.Compare(strings.ToLower(fileList[i].Path), strings.ToLower(fileList[j].Path))
+	}
+	compare := func(i, j int) int {
+		switch sortType {
+		case model.SortSize:
+			return compareInts(fileList[j].Size, fileList[i].Size)
+		case model.SortModified:
+			return -fileList[i].ModTime.Compare(fileList[j].ModTime)
+		case model.SortChildren:
+			return compareInts(int64(fileList[j].Children), int64(fileList[i].Children))
+		case model.SortExtension:
+			return strings.Compare(extension(fileList[i]), extension(fileList[j]))
+		case model.SortDirsFirst:
+			return compareBools(fileList[j].Dir, fileList[i].Dir)
+		default:
+			return 0
+		}
+	}
+	sort.SliceStable(fileList, func(i, j int) bool {
+		c := compare(i, j)
+		if c == 0 {
+			c = byName(i, j)
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func extension(file File) string {
+	if file.Dir {
+		return ""
+	}
+	return strings.ToLower(filepath.Ext(file.Path))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,50 @@
+package browser
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/l"
+	"github.com/kamackay/all/utils"
+)
+
+// How long a message stays in the status bar
+const messageDuration = 5 * time.Second
+
+type message struct {
+	Text  string
+	Error bool
+	Time  time.Time
+}
+
+// diskUsage describes the free space of the file system path is on
+func diskUsage(path string) string {
+	free, total, err := files.DiskUsage(path)
+	if err != nil {
+		l.Error(err)
+		return ""
+	}
+	return fmt.Sprintf("%s free of %s", utils.HumanizeBytes(free), utils.HumanizeBytes(total))
+}
+
+// selectionDetails describes the selected entry: permissions, owner, exact size and full modified time
+func (b *Browser) selectionDetails() string {
+	if b.SelectedLine < 0 || b.SelectedLine >= len(b.Files) {
+		return ""
+	}
+	file := b.Files[b.SelectedLine]
+	info, err := os.Lstat(file.Path)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s  %s  %s bytes  %s", info.Mode(), files.Owner(info),
+		humanize.Comma(file.Size), info.ModTime().Format("2006-01-02 15:04:05.000 -0700"))
+}
+
+// renderStatus draws the status bar on the bottom line with the selection details and free disk space
+func (b *Browser) renderStatus() {
+	b.renderStatusBar(b.selectionDetails(), b.diskUsage)
+}
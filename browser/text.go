@@ -0,0 +1,74 @@
+package browser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	ellipsis = "…"
+	tabWidth = 4
+)
+
+// textWidth is the number of terminal cells needed to display the given text
+func textWidth(text string) int {
+	return runewidth.StringWidth(text)
+}
+
+// displayRune maps runes that can't be drawn directly to something that can be
+func displayRune(r rune) rune {
+	if r == utf8.RuneError || unicode.IsControl(r) {
+		return '?'
+	}
+	return r
+}
+
+// truncateMiddle shortens the text to fit in width cells, replacing the middle with an ellipsis
+// so that both the start and the end of a long path stay visible
+func truncateMiddle(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if textWidth(text) <= width {
+		return text
+	}
+	if width <= textWidth(ellipsis) {
+		return runewidth.Truncate(text, width, "")
+	}
+	runes := []rune(text)
+	available := width - textWidth(ellipsis)
+	headWidth := (available + 1) / 2
+	tailWidth := available - headWidth
+
+	head := runewidth.Truncate(text, headWidth, "")
+	tail := ""
+	used := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		w := runewidth.RuneWidth(runes[i])
+		if used+w > tailWidth {
+			break
+		}
+		used += w
+		tail = string(runes[i]) + tail
+	}
+	return padRight(head+ellipsis+tail, width)
+}
+
+// padRight pads the text with spaces until it fills width cells
+func padRight(text string, width int) string {
+	if w := textWidth(text); w < width {
+		return text + strings.Repeat(" ", width-w)
+	}
+	return text
+}
+
+// padLeft right aligns the text in a column that is width cells wide
+func padLeft(text string, width int) string {
+	if w := textWidth(text); w < width {
+		return strings.Repeat(" ", width-w) + text
+	}
+	return text
+}
@@ -0,0 +1,121 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kamackay/all/config"
+	"github.com/nsf/termbox-go"
+)
+
+type style struct {
+	fg, bg termbox.Attribute
+}
+
+type theme struct {
+	header        style
+	selection     style
+	directory     style
+	file          style
+	largeFile     style
+	largeFileSize int64
+}
+
+var defaultTheme = theme{
+	header:        style{termbox.ColorLightMagenta, black},
+	selection:     style{black, green},
+	directory:     style{green | termbox.AttrBold, black},
+	file:          style{green, black},
+	largeFile:     style{termbox.ColorYellow, black},
+	largeFileSize: 1000000000,
+}
+
+var colors = map[string]termbox.Attribute{
+	"default":       termbox.ColorDefault,
+	"black":         termbox.ColorBlack,
+	"red":           termbox.ColorRed,
+	"green":         termbox.ColorGreen,
+	"yellow":        termbox.ColorYellow,
+	"blue":          termbox.ColorBlue,
+	"magenta":       termbox.ColorMagenta,
+	"cyan":          termbox.ColorCyan,
+	"white":         termbox.ColorWhite,
+	"dark-gray":     termbox.ColorDarkGray,
+	"light-red":     termbox.ColorLightRed,
+	"light-green":   termbox.ColorLightGreen,
+	"light-yellow":  termbox.ColorLightYellow,
+	"light-blue":    termbox.ColorLightBlue,
+	"light-magenta": termbox.ColorLightMagenta,
+	"light-cyan":    termbox.ColorLightCyan,
+	"light-gray":    termbox.ColorLightGray,
+}
+
+// parseColor reads names like "green" or "bold light-cyan"
+func parseColor(name string) (termbox.Attribute, error) {
+	var attr termbox.Attribute
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		switch word {
+		case "bold":
+			attr |= termbox.AttrBold
+		case "underline":
+			attr |= termbox.AttrUnderline
+		case "reverse":
+			attr |= termbox.AttrReverse
+		default:
+			color, ok := colors[word]
+			if !ok {
+				return attr, fmt.Errorf("unknown color %q", name)
+			}
+			attr |= color
+		}
+	}
+	return attr, nil
+}
+
+// override replaces the parts of the style that are set in the config
+func (s *style) override(c config.Style) []error {
+	errs := make([]error, 0)
+	if c.Fg != "" {
+		if fg, err := parseColor(c.Fg); err != nil {
+			errs = append(errs, err)
+		} else {
+			s.fg = fg
+		}
+	}
+	if c.Bg != "" {
+		if bg, err := parseColor(c.Bg); err != nil {
+			errs = append(errs, err)
+		} else {
+			s.bg = bg
+		}
+	}
+	return errs
+}
+
+func newTheme(c config.Theme) (theme, []error) {
+	t := defaultTheme
+	errs := make([]error, 0)
+	errs = append(errs, t.header.override(c.Header)...)
+	errs = append(errs, t.selection.override(c.Selection)...)
+	errs = append(errs, t.directory.override(c.Directory)...)
+	errs = append(errs, t.file.override(c.File)...)
+	errs = append(errs, t.largeFile.override(c.LargeFile)...)
+	if c.LargeFileSize > 0 {
+		t.largeFileSize = int64(c.LargeFileSize)
+	}
+	return t, errs
+}
+
+// fileStyle picks how a row in the list is drawn
+func (t theme) fileStyle(file File, selected bool) style {
+	switch {
+	case selected:
+		return t.selection
+	case file.Dir:
+		return t.directory
+	case file.Size >= t.largeFileSize:
+		return t.largeFile
+	default:
+		return t.file
+	}
+}
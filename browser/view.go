@@ -0,0 +1,247 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kamackay/all/config"
+	"github.com/kamackay/all/l"
+	"github.com/nsf/termbox-go"
+)
+
+// view is the terminal plumbing shared by the browser and the folder comparison: the screen and the input it sends
+// to the event loop, the keymap and theme from config.toml, the help overlay and status bar messages
+type view struct {
+	screen        Screen
+	Width, Height int
+	messages      chan interface{}
+	closed        chan struct{}
+	pausedChan    chan struct{}
+	resumeChan    chan struct{}
+	keys          *keymap
+	theme         theme
+	showHelp      bool
+	message       *message
+	quitting      bool
+}
+
+func newView(screen Screen) (view, error) {
+	if err := screen.Init(); err != nil {
+		return view{}, err
+	}
+	screen.SetInputMode(termbox.InputAlt | termbox.InputMouse)
+	w, h := screen.Size()
+	return view{
+		screen:     screen,
+		Width:      w,
+		Height:     h,
+		messages:   make(chan interface{}, messageBuffer),
+		closed:     make(chan struct{}),
+		pausedChan: make(chan struct{}),
+		resumeChan: make(chan struct{}),
+	}, nil
+}
+
+// configure applies the keymap and theme from config.toml, problems are reported and the defaults used instead
+func (v *view) configure() *config.Config {
+	cfg, err := config.Load()
+	v.reportError(err)
+	keys, errs := newKeymap(cfg.Keys)
+	t, themeErrs := newTheme(cfg.Theme)
+	for _, err := range append(errs, themeErrs...) {
+		v.reportError(err)
+	}
+	v.keys = keys
+	v.theme = t
+	return cfg
+}
+
+// poll turns terminal events into messages for the event loop
+func (v *view) poll() {
+	for {
+		event := v.screen.PollEvent()
+		switch event.Type {
+		case termbox.EventKey, termbox.EventMouse:
+			v.send(context.Background(), inputMsg{event: event})
+		case termbox.EventInterrupt:
+			// An external program is taking over the terminal, wait until it's handed back
+			v.pausedChan <- struct{}{}
+			<-v.resumeChan
+		case termbox.EventResize:
+			v.send(context.Background(), resizeMsg{width: event.Width, height: event.Height})
+		}
+	}
+}
+
+// send delivers a message to the loop, giving up if ctx is cancelled or the view closes first
+func (v *view) send(ctx context.Context, msg interface{}) bool {
+	select {
+	case v.messages <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-v.closed:
+		return false
+	}
+}
+
+// trySend delivers a message only if the loop has room for it, for updates that are fine to drop
+func (v *view) trySend(msg interface{}) {
+	select {
+	case v.messages <- msg:
+	default:
+	}
+}
+
+func (v *view) closeScreen() {
+	close(v.closed)
+	v.screen.Close()
+}
+
+func (v *view) setSize(height int, width int) {
+	l.Print(fmt.Sprintf("Setting Size to %dx%d", height, width))
+	v.Height = height
+	v.Width = width
+}
+
+// reportError logs the error and shows it in the status bar
+func (v *view) reportError(err error) {
+	if err == nil {
+		return
+	}
+	l.Error(err)
+	v.message = &message{Text: err.Error(), Error: true, Time: time.Now()}
+}
+
+// notify logs the text and shows it in the status bar
+func (v *view) notify(text string) {
+	l.Print(text)
+	v.message = &message{Text: text, Time: time.Now()}
+}
+
+func (v *view) drawString(line string, y int, fg, bg termbox.Attribute) {
+	v.drawStringAt(line, 0, y, v.Width, fg, bg)
+}
+
+func (v *view) drawStringAt(line string, x, y, maxWidth int, fg, bg termbox.Attribute) int {
+	return drawText(v.screen, line, x, y, maxWidth, fg, bg)
+}
+
+// renderHelp lists the active keymap
+func (v *view) renderHelp() {
+	v.drawString("Keys (change them in config.toml), press any key to close", 0, v.theme.header.fg, v.theme.header.bg)
+	for i, line := range v.keys.help() {
+		if i+2 >= v.Height {
+			break
+		}
+		v.drawString(line, i+2, green, black)
+	}
+}
+
+// renderStatusBar draws the bottom line with details on the left and right against the right edge,
+// messages take the place of details for a few seconds
+func (v *view) renderStatusBar(details string, right string) {
+	y := v.Height - 1
+	for x := 0; x < v.Width; x++ {
+		v.screen.SetCell(x, y, ' ', v.theme.header.fg, v.theme.header.bg)
+	}
+	rightWidth := textWidth(right)
+	if rightWidth < v.Width {
+		v.drawStringAt(right, v.Width-rightWidth, y, rightWidth, v.theme.header.fg, v.theme.header.bg)
+	} else {
+		rightWidth = 0
+	}
+	available := v.Width - rightWidth - 2
+	if m := v.message; m != nil && time.Since(m.Time) < messageDuration {
+		fg := v.theme.header.fg
+		if m.Error {
+			fg = termbox.ColorRed | termbox.AttrBold
+		}
+		v.drawStringAt(truncateMiddle(m.Text, available), 0, y, available, fg, v.theme.header.bg)
+		return
+	}
+	v.drawStringAt(details, 0, y, available, v.theme.header.fg, v.theme.header.bg)
+}
+
+// cursor is the selected row and scroll position of a list drawn below the header
+type cursor struct {
+	SelectedLine int
+	offset       int
+}
+
+// moveTo selects row i of count rows, scrolling to keep it inside a viewport of page rows
+func (c *cursor) moveTo(i, count, page int) {
+	if i >= count {
+		i = count - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	c.SelectedLine = i
+	if i < c.offset {
+		c.offset = i
+	} else if i >= c.offset+page {
+		c.offset = i - page + 1
+	}
+	c.clampOffset(count, page)
+}
+
+func (c *cursor) clampOffset(count, page int) {
+	if max := count - page; c.offset > max {
+		c.offset = max
+	}
+	if c.offset < 0 {
+		c.offset = 0
+	}
+}
+
+// scrollTo moves the viewport to start at the given row, dragging the selection along if it would leave the screen
+func (c *cursor) scrollTo(offset, count, page int) {
+	c.offset = offset
+	c.clampOffset(count, page)
+	if c.SelectedLine < c.offset {
+		c.SelectedLine = c.offset
+	} else if c.SelectedLine >= c.offset+page {
+		c.SelectedLine = c.offset + page - 1
+	}
+}
+
+// navigate handles the actions that move through the list, returns false if the action isn't one of them
+func (c *cursor) navigate(a action, count, page int) bool {
+	switch a {
+	case actionUp:
+		c.moveTo(c.SelectedLine-1, count, page)
+	case actionDown:
+		c.moveTo(c.SelectedLine+1, count, page)
+	case actionPageUp:
+		c.moveTo(c.SelectedLine-page, count, page)
+	case actionPageDown:
+		c.moveTo(c.SelectedLine+page, count, page)
+	case actionTop:
+		c.moveTo(0, count, page)
+	case actionBottom:
+		c.moveTo(count-1, count, page)
+	default:
+		return false
+	}
+	return true
+}
+
+// mouse scrolls the list with the wheel and selects the row that was clicked, the list starts below the header
+func (c *cursor) mouse(e termbox.Event, count, page int) {
+	switch e.Key {
+	case termbox.MouseWheelUp:
+		c.scrollTo(c.offset-wheelStep, count, page)
+	case termbox.MouseWheelDown:
+		c.scrollTo(c.offset+wheelStep, count, page)
+	case termbox.MouseLeft:
+		if e.MouseY < 1 {
+			// Header line
+			break
+		}
+		if i := c.offset + e.MouseY - 1; i < count {
+			c.moveTo(i, count, page)
+		}
+	}
+}
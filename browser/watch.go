@@ -0,0 +1,162 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kamackay/all/l"
+)
+
+const (
+	// How long to gather file system events before refreshing, so a burst of them becomes one refresh
+	watchDebounce = 200 * time.Millisecond
+	// Upper limit on folders watched when watching a whole subtree, inotify watches are a limited resource
+	maxWatches = 4096
+)
+
+var errTooManyWatches = errors.New("too many folders to watch")
+
+// startWatching watches the current folder (and everything below it if watchSubtree is set) for changes,
+// replacing any previous watcher. If watching isn't possible the browser falls back to polling
+func (b *Browser) startWatching() {
+	b.stopWatching()
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		b.reportError(err)
+		return
+	}
+	root := b.path
+	if b.watchSubtree {
+		err = addSubtree(w, root)
+	} else {
+		err = w.Add(root)
+	}
+	if errors.Is(err, errTooManyWatches) {
+		b.notify(fmt.Sprintf("Only watching the first %d folders under %s", maxWatches, root))
+	} else if err != nil {
+		b.reportError(err)
+		l.Error(w.Close())
+		return
+	}
+	b.watcher = w
+	go b.watch(w, root, b.watchSubtree)
+}
+
+func (b *Browser) stopWatching() {
+	if b.watcher == nil {
+		return
+	}
+	l.Error(b.watcher.Close())
+	b.watcher = nil
+}
+
+func addSubtree(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if len(w.WatchList()) >= maxWatches {
+			return errTooManyWatches
+		}
+		if err := w.Add(path); err != nil {
+			l.Error(err)
+		}
+		return nil
+	})
+}
+
+// watch forwards events to the loop until the watcher is closed
+func (b *Browser) watch(w *fsnotify.Watcher, root string, subtree bool) {
+	ctx := context.Background()
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if subtree && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addSubtree(w, event.Name); err != nil {
+						b.send(ctx, errorMsg{err: err})
+					}
+				}
+			}
+			b.send(ctx, changedMsg{root: root, path: event.Name})
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			b.send(ctx, errorMsg{err: err})
+		}
+	}
+}
+
+// changed queues the entry of root that contains path to be refreshed shortly
+func (b *Browser) changed(root string, path string) {
+	if root != b.path || b.watcher == nil {
+		// Left over from a folder that isn't being watched anymore
+		return
+	}
+	child := root
+	if rel, err := filepath.Rel(root, path); err == nil && rel != "." {
+		child = filepath.Join(root, strings.Split(rel, string(filepath.Separator))[0])
+	}
+	b.pendingChanges[child] = true
+	if b.debounce == nil {
+		// Not pushed back by later events, or a file that keeps changing would hold off the refresh forever
+		b.debounce = time.After(watchDebounce)
+	}
+}
+
+// flushChanges refreshes the entries queued up by changed, recalculating just those instead of rescanning the whole folder
+func (b *Browser) flushChanges() {
+	changes := b.pendingChanges
+	b.pendingChanges = make(map[string]bool)
+	root := b.path
+	if len(changes) == 0 {
+		return
+	}
+	if changes[root] {
+		// Something happened to the folder itself
+		b.getFiles(false)
+		return
+	}
+	go func() {
+		fileList := make([]File, 0, len(changes))
+		for path := range changes {
+			info, err := os.Lstat(path)
+			if err != nil {
+				// Deleted
+				continue
+			}
+			fileList = append(fileList, makeFile(root, info))
+		}
+		b.send(context.Background(), entriesMsg{root: root, changed: changes, files: fileList})
+	}()
+}
+
+// refreshed swaps the recalculated entries into the list
+func (b *Browser) refreshed(m entriesMsg) {
+	if b.filesPath != m.root || len(b.Files) == 0 {
+		return
+	}
+	b.rememberPosition()
+	fileList := make([]File, 0, len(b.Files))
+	for _, file := range b.Files[1:] {
+		if !m.changed[file.Path] {
+			fileList = append(fileList, file)
+		}
+	}
+	fileList = append(fileList, m.files...)
+	sortFiles(fileList, b.sort, b.reverse)
+	b.Files = append([]File{b.Files[0]}, fileList...)
+	b.updatedString = time.Now().Format("2006-01-02 15:04:05")
+	b.restorePosition()
+}
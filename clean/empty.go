@@ -0,0 +1,128 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var (
+	// DefaultJunk are files the OS leaves behind that don't count as content, a folder holding only these is empty
+	DefaultJunk = []string{".DS_Store", "Thumbs.db", "desktop.ini"}
+	// DefaultKeepEmpty are files that are meant to be empty, they're never removed and keep their folder around
+	DefaultKeepEmpty = []string{"__init__.py", ".gitkeep", ".keep", ".nojekyll"}
+)
+
+// readDir lists a folder, a variable so tests can stand in folders that can't be read
+var readDir = os.ReadDir
+
+// Step is a single removal, steps are ordered so everything in a folder comes before the folder itself
+type Step struct {
+	Path string
+	Dir  bool
+	Junk bool
+	Size int64
+}
+
+type Plan struct {
+	Steps []Step
+	Dirs  int
+	Files int
+	Junk  int
+	Bytes int64
+}
+
+func (p *Plan) add(step Step) {
+	p.Steps = append(p.Steps, step)
+	switch {
+	case step.Dir:
+		p.Dirs++
+	case step.Junk:
+		p.Junk++
+	default:
+		p.Files++
+	}
+	p.Bytes += step.Size
+}
+
+// EmptyOptions pick what Empty looks for
+type EmptyOptions struct {
+	// Files removes zero byte files
+	Files bool
+	// Dirs removes folders that are empty once everything below them that can go is gone
+	Dirs bool
+	// Junk are file names that go along with a folder that's otherwise empty
+	Junk []string
+	// KeepEmpty are names or globs of empty files that are left alone
+	KeepEmpty []string
+}
+
+type emptyFinder struct {
+	EmptyOptions
+	junk map[string]bool
+	plan *Plan
+}
+
+// Empty plans the removal of empty files and folders under root, bottom up so removing a folder's contents can
+// make it empty too. root itself is never removed. Folders that can't be read count as not empty
+func Empty(root string, opts EmptyOptions) *Plan {
+	f := &emptyFinder{EmptyOptions: opts, junk: make(map[string]bool, len(opts.Junk)), plan: &Plan{Steps: make([]Step, 0)}}
+	for _, name := range opts.Junk {
+		f.junk[name] = true
+	}
+	f.dir(root, true)
+	return f.plan
+}
+
+func (f *emptyFinder) keep(name string) bool {
+	for _, pattern := range f.KeepEmpty {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// dir adds the steps for dir's contents, and dir itself if nothing would be left in it. Returns whether it's empty
+func (f *emptyFinder) dir(dir string, root bool) bool {
+	entries, err := readDir(dir)
+	if err != nil {
+		return false
+	}
+	empty := true
+	junkFiles := make([]Step, 0)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			// Symlinks aren't followed, DirEntry reports them as links rather than folders
+			if !f.dir(path, false) {
+				empty = false
+			}
+			continue
+		}
+		if !entry.Type().IsRegular() || f.keep(entry.Name()) {
+			empty = false
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			empty = false
+			continue
+		}
+		switch {
+		case f.junk[entry.Name()]:
+			junkFiles = append(junkFiles, Step{Path: path, Junk: true, Size: info.Size()})
+		case f.Files && info.Size() == 0:
+			f.plan.add(Step{Path: path})
+		default:
+			empty = false
+		}
+	}
+	if !empty || root || !f.Dirs {
+		return empty
+	}
+	for _, step := range junkFiles {
+		f.plan.add(step)
+	}
+	f.plan.add(Step{Path: dir, Dir: true})
+	return true
+}
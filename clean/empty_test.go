@@ -0,0 +1,221 @@
+package clean
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeTree creates the entries under root, names ending in a slash are folders and everything else is a file
+// holding its contents
+func makeTree(t *testing.T, root string, entries map[string]string) {
+	t.Helper()
+	for name, contents := range entries {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// planned lists the steps relative to root, in order, with folders ending in a slash
+func planned(t *testing.T, root string, plan *Plan) string {
+	t.Helper()
+	steps := make([]string, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		rel, err := filepath.Rel(root, step.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = filepath.ToSlash(rel)
+		if step.Dir {
+			rel += "/"
+		}
+		steps = append(steps, rel)
+	}
+	return strings.Join(steps, " ")
+}
+
+var emptyDirs = EmptyOptions{Dirs: true, Junk: DefaultJunk, KeepEmpty: DefaultKeepEmpty}
+
+func TestEmptyDirs(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    string
+	}{
+		{
+			name:    "nested folders cascade up",
+			entries: map[string]string{"a/b/c/": "", "a/b/d/": "", "keep/file.txt": "content"},
+			want:    "a/b/c/ a/b/d/ a/b/ a/",
+		},
+		{
+			name:    "content anywhere below keeps the folders above it",
+			entries: map[string]string{"a/b/c/": "", "a/file.txt": "content"},
+			want:    "a/b/c/ a/b/",
+		},
+		{
+			name: "junk goes with its folder",
+			entries: map[string]string{
+				"photos/.DS_Store":     "junk",
+				"photos/Thumbs.db":     "junk",
+				"photos/old/":          "",
+				"music/.DS_Store":      "junk",
+				"music/song.mp3":       "content",
+				"music/live/Thumbs.db": "junk",
+			},
+			want: "music/live/Thumbs.db music/live/ photos/old/ photos/.DS_Store photos/Thumbs.db photos/",
+		},
+		{
+			name:    "root is never removed",
+			entries: map[string]string{".DS_Store": "junk", "a/": ""},
+			want:    "a/",
+		},
+		{
+			name:    "empty files count as content unless files are removed too",
+			entries: map[string]string{"a/zero": ""},
+			want:    "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			makeTree(t, root, test.entries)
+			if got := planned(t, root, Empty(root, emptyDirs)); got != test.want {
+				t.Errorf("planned %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEmptyCounts(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]string{"a/b/": "", "a/.DS_Store": "1234", "c/Thumbs.db": "12"})
+	plan := Empty(root, emptyDirs)
+	if plan.Dirs != 3 || plan.Files != 0 || plan.Junk != 2 || plan.Bytes != 6 {
+		t.Errorf("counted %d dirs, %d files, %d junk and %d bytes, want 3, 0, 2 and 6",
+			plan.Dirs, plan.Files, plan.Junk, plan.Bytes)
+	}
+	// Planning doesn't touch anything
+	if _, err := os.Stat(filepath.Join(root, "a", ".DS_Store")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmptyKeepsUnreadableFolders(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]string{"a/locked/": "", "b/": ""})
+	locked := filepath.Join(root, "a", "locked")
+	readDir = func(dir string) ([]os.DirEntry, error) {
+		if dir == locked {
+			return nil, os.ErrPermission
+		}
+		return os.ReadDir(dir)
+	}
+	t.Cleanup(func() { readDir = os.ReadDir })
+	if got := planned(t, root, Empty(root, emptyDirs)); got != "b/" {
+		t.Errorf("planned %q, want only b/", got)
+	}
+
+	readDir = func(string) ([]os.DirEntry, error) { return nil, errors.New("unreadable") }
+	if plan := Empty(root, emptyDirs); len(plan.Steps) != 0 {
+		t.Errorf("planned %d steps for a root that can't be read", len(plan.Steps))
+	}
+}
+
+func TestEmptyLeavesSymlinks(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	makeTree(t, outside, map[string]string{"empty/": "", "zero": ""})
+	makeTree(t, root, map[string]string{"dirlink/": "", "filelink/": "", "dangling/": ""})
+	links := map[string]string{
+		"dirlink/to-empty": filepath.Join(outside, "empty"),
+		"filelink/to-zero": filepath.Join(outside, "zero"),
+		"dangling/nowhere": filepath.Join(outside, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Skipf("Can't create symlinks: %v", err)
+		}
+	}
+	opts := emptyDirs
+	opts.Files = true
+	if got := planned(t, root, Empty(root, opts)); got != "" {
+		t.Errorf("planned %q, links should be left alone and keep their folders", got)
+	}
+}
+
+func TestEmptyFiles(t *testing.T) {
+	entries := map[string]string{
+		"zero.txt":          "",
+		"full.txt":          "content",
+		"logs/a.log":        "",
+		"logs/b.log":        "",
+		"pkg/__init__.py":   "",
+		"pkg/mod.py":        "",
+		"site/.gitkeep":     "",
+		"site/.nojekyll":    "",
+		"deps/yarn.lock":    "",
+		"deps/tmp/":         "",
+		"mixed/zero":        "",
+		"mixed/.DS_Store":   "junk",
+		"mixed/nested/zero": "",
+	}
+	tests := []struct {
+		name string
+		opts EmptyOptions
+		want string
+	}{
+		{
+			name: "files only leaves every folder",
+			opts: EmptyOptions{Files: true, Junk: DefaultJunk, KeepEmpty: DefaultKeepEmpty},
+			want: "deps/yarn.lock logs/a.log logs/b.log mixed/nested/zero mixed/zero pkg/mod.py zero.txt",
+		},
+		{
+			name: "files and dirs take the folders that only held empty files",
+			opts: EmptyOptions{Files: true, Dirs: true, Junk: DefaultJunk, KeepEmpty: DefaultKeepEmpty},
+			want: "deps/tmp/ deps/yarn.lock deps/ logs/a.log logs/b.log logs/ mixed/nested/zero mixed/nested/ " +
+				"mixed/zero mixed/.DS_Store mixed/ pkg/mod.py zero.txt",
+		},
+		{
+			name: "keep_empty globs",
+			opts: EmptyOptions{Files: true, Dirs: true, Junk: DefaultJunk, KeepEmpty: []string{"*.lock", "*.log"}},
+			want: "deps/tmp/ mixed/nested/zero mixed/nested/ mixed/zero mixed/.DS_Store mixed/ pkg/__init__.py " +
+				"pkg/mod.py pkg/ site/.gitkeep site/.nojekyll site/ zero.txt",
+		},
+		{
+			name: "dirs only keeps empty files and their folders",
+			opts: emptyDirs,
+			want: "deps/tmp/",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			makeTree(t, root, entries)
+			if got := planned(t, root, Empty(root, test.opts)); got != test.want {
+				t.Errorf("planned %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEmptyFilesCounts(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, map[string]string{"a/zero": "", "a/.DS_Store": "junk", "b/zero": "", "b/__init__.py": ""})
+	plan := Empty(root, EmptyOptions{Files: true, Dirs: true, Junk: DefaultJunk, KeepEmpty: DefaultKeepEmpty})
+	if plan.Dirs != 1 || plan.Files != 2 || plan.Junk != 1 || plan.Bytes != 4 {
+		t.Errorf("counted %d dirs, %d files, %d junk and %d bytes, want 1, 2, 1 and 4",
+			plan.Dirs, plan.Files, plan.Junk, plan.Bytes)
+	}
+}
@@ -0,0 +1,247 @@
+package clean
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/model"
+	"gopkg.in/yaml.v3"
+)
+
+type Action string
+
+const (
+	ActionReport Action = "report"
+	ActionTrash  Action = "trash"
+	ActionDelete Action = "delete"
+)
+
+// Rule matches entries by every predicate that's set and applies its action to them
+type Rule struct {
+	Name string `yaml:"name"`
+	// Glob is matched against the entry's name, or its path relative to the folder being cleaned if it has a /
+	Glob string `yaml:"glob"`
+	// Type is file, dir, video, audio or image, anything matches when it's left out
+	Type string `yaml:"type"`
+	// Sizes like 0, 512kB or 1GB, folders count everything inside them
+	MinSize string `yaml:"min_size"`
+	MaxSize string `yaml:"max_size"`
+	// Ages like 12h, 30d or 2w since the entry was modified, folders count the newest thing inside them
+	OlderThan string `yaml:"older_than"`
+	NewerThan string `yaml:"newer_than"`
+	Action    Action `yaml:"action"`
+
+	minSize   *uint64
+	maxSize   *uint64
+	olderThan time.Duration
+	newerThan time.Duration
+}
+
+type rulesFile struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// LoadRules reads and checks a rules file
+func LoadRules(path string) ([]*Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	var file rulesFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", path)
+	}
+	for i, rule := range file.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := rule.parse(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, rule.Name, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+func (r *Rule) parse() error {
+	switch r.Action {
+	case ActionReport, ActionTrash, ActionDelete:
+	case "":
+		return fmt.Errorf("no action, expected report, trash or delete")
+	default:
+		return fmt.Errorf("unknown action %q, expected report, trash or delete", r.Action)
+	}
+	switch r.Type {
+	case "", "file", "dir", "video", "audio", "image":
+	default:
+		return fmt.Errorf("unknown type %q, expected file, dir, video, audio or image", r.Type)
+	}
+	if _, err := filepath.Match(r.Glob, ""); err != nil {
+		return fmt.Errorf("glob %q: %w", r.Glob, err)
+	}
+	var err error
+	if r.minSize, err = parseSize(r.MinSize); err != nil {
+		return err
+	}
+	if r.maxSize, err = parseSize(r.MaxSize); err != nil {
+		return err
+	}
+	if r.olderThan, err = parseAge(r.OlderThan); err != nil {
+		return err
+	}
+	if r.newerThan, err = parseAge(r.NewerThan); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseSize(value string) (*uint64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("size %q: %w", value, err)
+	}
+	return &size, nil
+}
+
+// parseAge reads Go durations plus days and weeks, like 30d or 2w
+func parseAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+		if n, err := strconv.ParseFloat(strings.TrimSuffix(value, suffix), 64); err == nil {
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("age %q, expected something like 36h, 30d or 2w", value)
+	}
+	return age, nil
+}
+
+// matches checks the entry against every predicate of the rule, the media type is only sniffed when it's needed
+func (r *Rule) matches(bean *model.FileBean, rel string, modified time.Time, now time.Time) bool {
+	if r.Glob != "" {
+		name := filepath.Base(rel)
+		if strings.Contains(r.Glob, "/") {
+			name = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(r.Glob, name); !ok {
+			return false
+		}
+	}
+	switch {
+	case r.Type == "dir" && !bean.IsDir(), r.Type != "" && r.Type != "dir" && bean.IsDir():
+		return false
+	case r.minSize != nil && bean.Size < *r.minSize, r.maxSize != nil && bean.Size > *r.maxSize:
+		return false
+	case r.olderThan > 0 && now.Sub(modified) < r.olderThan, r.newerThan > 0 && now.Sub(modified) > r.newerThan:
+		return false
+	}
+	switch r.Type {
+	case "video", "audio", "image":
+		return files.DetectMedia(bean.Name).String() == r.Type
+	}
+	return true
+}
+
+type Match struct {
+	Path string
+	Dir  bool
+	Size uint64
+}
+
+// RuleMatches are the entries a rule applies to
+type RuleMatches struct {
+	Rule    *Rule
+	Matches []Match
+	Size    uint64
+}
+
+// Find applies the rules to everything under root, in the order they're listed. Each entry goes to the first rule that
+// matches it, and nothing inside a matched folder is looked at again
+func Find(beans []*model.FileBean, root string, rules []*Rule, now time.Time) []*RuleMatches {
+	beans = append([]*model.FileBean(nil), beans...)
+	sort.Slice(beans, func(i, j int) bool {
+		return beans[i].Name < beans[j].Name
+	})
+	newest := newestModified(beans, root)
+	results := make([]*RuleMatches, len(rules))
+	for i, rule := range rules {
+		results[i] = &RuleMatches{Rule: rule, Matches: make([]Match, 0)}
+	}
+	matchedDirs := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, bean := range beans {
+		if bean.Name == root || seen[bean.Name] || insideMatch(bean.Name, root, matchedDirs) {
+			continue
+		}
+		seen[bean.Name] = true
+		rel, err := filepath.Rel(root, bean.Name)
+		if err != nil {
+			continue
+		}
+		modified := bean.LastModified()
+		if bean.IsDir() {
+			modified = newest[bean.Name]
+		}
+		for i, rule := range rules {
+			if !rule.matches(bean, rel, modified, now) {
+				continue
+			}
+			results[i].Matches = append(results[i].Matches, Match{Path: bean.Name, Dir: bean.IsDir(), Size: bean.Size})
+			results[i].Size += bean.Size
+			if bean.IsDir() {
+				matchedDirs[bean.Name] = true
+			}
+			break
+		}
+	}
+	return results
+}
+
+func insideMatch(path string, root string, matchedDirs map[string]bool) bool {
+	for dir := filepath.Dir(path); dir != root && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if matchedDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// newestModified is the latest modified time of each folder or anything inside it
+func newestModified(beans []*model.FileBean, root string) map[string]time.Time {
+	newest := make(map[string]time.Time)
+	for _, bean := range beans {
+		modified := bean.LastModified()
+		for dir := bean.Name; ; dir = filepath.Dir(dir) {
+			if modified.After(newest[dir]) {
+				newest[dir] = modified
+			}
+			if dir == root || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	return newest
+}
@@ -0,0 +1,187 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamackay/all/files"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		err   bool
+	}{
+		{value: "", want: 0},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "1.5d", want: 36 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "d", err: true},
+		{value: "10x", err: true},
+		{value: "soon", err: true},
+	}
+	for _, test := range tests {
+		got, err := parseAge(test.value)
+		if (err != nil) != test.err {
+			t.Errorf("parseAge(%q) error = %v, want error %t", test.value, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseAge(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func writeRules(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules(writeRules(t, `
+rules:
+  - name: old temp files
+    glob: "*.tmp"
+    type: file
+    min_size: 1kB
+    older_than: 30d
+    action: delete
+  - glob: node_modules
+    type: dir
+    action: trash
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	if r := rules[0]; r.Name != "old temp files" || r.Action != ActionDelete || *r.minSize != 1000 ||
+		r.maxSize != nil || r.olderThan != 30*24*time.Hour {
+		t.Errorf("first rule parsed as %+v", r)
+	}
+	if r := rules[1]; r.Name != "rule 2" || r.Action != ActionTrash {
+		t.Errorf("unnamed rule parsed as %+v", r)
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{name: "no rules", rules: "rules: []\n", err: "has no rules"},
+		{name: "unknown field", rules: "rules:\n  - glob: '*'\n    older: 1d\n    action: report\n", err: "field older not found"},
+		{name: "no action", rules: "rules:\n  - glob: '*'\n", err: "no action"},
+		{name: "unknown action", rules: "rules:\n  - action: shred\n", err: `unknown action "shred"`},
+		{name: "unknown type", rules: "rules:\n  - type: socket\n    action: report\n", err: `unknown type "socket"`},
+		{name: "bad glob", rules: "rules:\n  - glob: '[a'\n    action: report\n", err: "glob"},
+		{name: "bad size", rules: "rules:\n  - min_size: lots\n    action: report\n", err: `size "lots"`},
+		{name: "bad age", rules: "rules:\n  - name: stale\n    newer_than: yesterday\n    action: report\n", err: `stale: age "yesterday"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadRules(writeRules(t, test.rules))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("missing rules file should fail")
+	}
+}
+
+// writeTree creates files of the given sizes modified the given number of days before now, folders are set to
+// a year old afterwards so only their contents say how recently they changed
+func writeTree(t *testing.T, root string, now time.Time, entries map[string][2]int) {
+	t.Helper()
+	for name, entry := range entries {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, entry[0]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modified := now.AddDate(0, 0, -entry[1])
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yearAgo := now.AddDate(-1, 0, 0)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return err
+		}
+		return os.Chtimes(path, yearAgo, yearAgo)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	now := time.Now()
+	root := t.TempDir()
+	// Size in bytes and age in days
+	writeTree(t, root, now, map[string][2]int{
+		"old.tmp":                          {100, 60},
+		"new.tmp":                          {100, 1},
+		"notes.txt":                        {50, 60},
+		"recent.txt":                       {50, 2},
+		"stale/node_modules/lib/index.js":  {300, 200},
+		"stale/node_modules/cache.tmp":     {200, 200},
+		"active/node_modules/lib/index.js": {300, 200},
+		"active/node_modules/fresh.js":     {10, 3},
+	})
+	rules := []*Rule{
+		{Name: "old temp", Glob: "*.tmp", Type: "file", OlderThan: "30d", Action: ActionDelete},
+		{Name: "stale modules", Glob: "node_modules", Type: "dir", OlderThan: "90d", Action: ActionTrash},
+		{Name: "old files", Type: "file", OlderThan: "30d", Action: ActionReport},
+	}
+	for _, rule := range rules {
+		if err := rule.parse(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results := Find(files.GetFilesRecursive(root), root, rules, now)
+
+	want := map[string][]string{
+		// Claimed by the first rule, even though the last one matches too
+		"old temp": {"old.tmp"},
+		// Only the folder, not the old files inside it. The active one has a recent file, so it isn't stale
+		// even though the folder itself was last modified a year ago
+		"stale modules": {"stale/node_modules"},
+		"old files":     {"active/node_modules/lib/index.js", "notes.txt"},
+	}
+	for _, result := range results {
+		got := make([]string, 0, len(result.Matches))
+		var size uint64
+		for _, m := range result.Matches {
+			rel, _ := filepath.Rel(root, m.Path)
+			got = append(got, filepath.ToSlash(rel))
+			size += m.Size
+		}
+		if strings.Join(got, ",") != strings.Join(want[result.Rule.Name], ",") {
+			t.Errorf("%s matched %v, want %v", result.Rule.Name, got, want[result.Rule.Name])
+		}
+		if size != result.Size {
+			t.Errorf("%s size is %d, matches add up to %d", result.Rule.Name, result.Size, size)
+		}
+	}
+	if stale := results[1]; len(stale.Matches) == 1 && (stale.Size != 500 || !stale.Matches[0].Dir) {
+		t.Errorf("stale node_modules should be a 500 byte folder, got %+v", stale.Matches[0])
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/fatih/color"
+	"github.com/kamackay/all/clean"
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/model"
+	"github.com/kamackay/all/utils"
+)
+
+const cleanCommand = "clean"
+
+// runClean is `all clean --rules rules.yaml [directory]`
+func runClean(args []string) {
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+	var opts model.CleanOpts
+	parser, err := kong.New(&opts, kong.Name("all "+cleanCommand),
+		kong.Description("Report, trash or delete whatever matches the rules in a YAML file"))
+	if err != nil {
+		red.Printf("%+v\n", err)
+		return
+	}
+	_, err = parser.Parse(args)
+	parser.FatalIfErrorf(err)
+
+	rules, err := clean.LoadRules(opts.Rules)
+	if err != nil {
+		red.Printf("%+v\n", err)
+		return
+	}
+	base, err := filepath.Abs(opts.Directory)
+	if err != nil {
+		red.Printf("%+v\n", err)
+		return
+	}
+	var total uint64
+	for _, result := range clean.Find(files.GetFilesRecursive(base), base, rules, time.Now()) {
+		rule := result.Rule
+		fmt.Printf("%s (%s): %d matches, %s\n", rule.Name, rule.Action, len(result.Matches),
+			utils.HumanizeBytes(result.Size))
+		if opts.Verbose || opts.DryRun || rule.Action == clean.ActionReport {
+			for _, m := range result.Matches {
+				fmt.Printf("  %s%s- %s\n", utils.HumanizeBytes(m.Size), utils.Spaces(11-len(utils.HumanizeBytes(m.Size))),
+					m.Path)
+			}
+		}
+		if rule.Action == clean.ActionReport || opts.DryRun || len(result.Matches) == 0 {
+			continue
+		}
+		verb, done := "Delete", "Deleted"
+		if rule.Action == clean.ActionTrash {
+			verb, done = "Trash", "Trashed"
+		}
+		question := fmt.Sprintf("%s %d entries (%s) matched by %q?", verb, len(result.Matches),
+			utils.HumanizeBytes(result.Size), rule.Name)
+		if !opts.Yes && !utils.AskForConfirmation(question) {
+			continue
+		}
+		var reclaimed uint64
+		removed := 0
+		for _, m := range result.Matches {
+			if rule.Action == clean.ActionTrash {
+				err = files.Trash(m.Path)
+			} else {
+				err = os.RemoveAll(m.Path)
+			}
+			if err != nil {
+				red.Printf("Could not %s %s: %+v\n", rule.Action, m.Path, err)
+				continue
+			}
+			removed++
+			reclaimed += m.Size
+		}
+		total += reclaimed
+		green.Printf("%s: %s %d of %d, reclaimed %s\n", rule.Name, done, removed,
+			len(result.Matches), utils.HumanizeBytes(reclaimed))
+	}
+	if !opts.DryRun {
+		fmt.Printf("Reclaimed %s in total\n", utils.HumanizeBytes(total))
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	appName       = "all"
+	bookmarksFile = "bookmarks.json"
+	configFile    = "config.toml"
+)
+
+// Config is the user editable config.toml, anything left out keeps its built in default
+type Config struct {
+	// Keys maps browser action names to the keys that trigger them, e.g. down = ["Down", "j"]
+	Keys  map[string][]string `toml:"keys"`
+	Theme Theme               `toml:"theme"`
+	// OpenWith maps file extensions to the command used to open them, {} is replaced with the file's path
+	OpenWith map[string]string `toml:"open_with"`
+	Video    Video             `toml:"video"`
+	Clean    Clean             `toml:"clean"`
+}
+
+type Clean struct {
+	// Junk are file names that don't count as content when looking for empty folders, like .DS_Store
+	Junk []string `toml:"junk"`
+	// KeepEmpty are names or globs of files that are meant to be empty, like __init__.py, which --rm-empty leaves alone
+	KeepEmpty []string `toml:"keep_empty"`
+}
+
+type Video struct {
+	// Targets maps codec names as ffprobe reports them, like h264 or hevc, to the video bit rate in Mbit/s
+	// a 1080p 30fps file with that codec is expected to need, "default" covers codecs that aren't listed
+	Targets map[string]float64 `toml:"targets"`
+	// Preset is the name of the preset --transcode uses when --preset isn't given
+	Preset string `toml:"preset"`
+	// Presets maps names to the ffmpeg arguments placed between the input and output files
+	Presets map[string]string `toml:"presets"`
+}
+
+type Theme struct {
+	Header    Style `toml:"header"`
+	Selection Style `toml:"selection"`
+	Directory Style `toml:"directory"`
+	File      Style `toml:"file"`
+	LargeFile Style `toml:"large_file"`
+	// Files at least this many bytes are drawn in the large_file style
+	LargeFileSize uint64 `toml:"large_file_size"`
+}
+
+// Style is a pair of color names like "green" or "light-magenta", optionally prefixed with "bold "
+type Style struct {
+	Fg string `toml:"fg"`
+	Bg string `toml:"bg"`
+}
+
+// Dir is the folder holding all's config files, created if it doesn't exist yet
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, appName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func file(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Load reads config.toml, a missing file is the same as an empty one
+func Load() (*Config, error) {
+	cfg := &Config{Keys: make(map[string][]string)}
+	path, err := file(configFile)
+	if err != nil {
+		return cfg, err
+	}
+	_, err = toml.DecodeFile(path, cfg)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	return cfg, err
+}
+
+// LoadBookmarks reads the saved browser bookmarks, keyed by the letter they were marked with
+func LoadBookmarks() (map[string]string, error) {
+	bookmarks := make(map[string]string)
+	path, err := file(bookmarksFile)
+	if err != nil {
+		return bookmarks, err
+	}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing has been bookmarked yet
+		return bookmarks, nil
+	} else if err != nil {
+		return bookmarks, err
+	}
+	err = json.Unmarshal(contents, &bookmarks)
+	if bookmarks == nil {
+		// The file held null
+		bookmarks = make(map[string]string)
+	}
+	return bookmarks, err
+}
+
+func SaveBookmarks(bookmarks map[string]string) error {
+	path, err := file(bookmarksFile)
+	if err != nil {
+		return err
+	}
+	contents, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0644)
+}
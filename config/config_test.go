@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBookmarks(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     int
+		err      bool
+	}{
+		{name: "missing"},
+		{name: "saved", contents: `{"a": "/tmp", "b": "/home"}`, want: 2},
+		{name: "null", contents: "null"},
+		{name: "empty object", contents: "{}"},
+		{name: "broken", contents: `{"a": `, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			if test.contents != "" {
+				path, err := file(bookmarksFile)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(test.contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bookmarks, err := LoadBookmarks()
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error %t", err, test.err)
+			}
+			if len(bookmarks) != test.want {
+				t.Errorf("got %d bookmarks, want %d", len(bookmarks), test.want)
+			}
+			// Marking a folder must always work, whatever was in the file
+			bookmarks["z"] = filepath.Join("some", "folder")
+		})
+	}
+}
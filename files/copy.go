@@ -0,0 +1,99 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Progress is told the total number of bytes copied so far
+type Progress = func(copied int64)
+
+type progressWriter struct {
+	io.Writer
+	copied   *int64
+	progress Progress
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	*w.copied += int64(n)
+	w.progress(*w.copied)
+	return n, err
+}
+
+// Copy copies a file or a whole folder to dst, keeping permissions
+func Copy(src string, dst string, progress Progress) error {
+	var copied int64
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm(), &copied, progress)
+		}
+	})
+}
+
+func copyFile(src string, dst string, mode os.FileMode, copied *int64, progress Progress) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(progressWriter{Writer: out, copied: copied, progress: progress}, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Move renames src to dst, copying and then deleting the original when they're on different file systems
+func Move(src string, dst string, progress Progress) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := Copy(src, dst, progress); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// TotalSize is the number of bytes in a file or everything under a folder
+func TotalSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	size, _ := GetFolderInfo(path, make(FileCache))
+	return int64(size)
+}
@@ -0,0 +1,13 @@
+package files
+
+import "syscall"
+
+// DiskUsage returns the free and total bytes of the file system path is on, OpenBSD's statfs names its fields differently
+func DiskUsage(path string) (free uint64, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.F_bsize)
+	return uint64(stat.F_bavail) * blockSize, stat.F_blocks * blockSize, nil
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package files
+
+import "syscall"
+
+// DiskUsage returns the free and total bytes of the file system path is on
+func DiskUsage(path string) (free uint64, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return uint64(stat.Bavail) * blockSize, uint64(stat.Blocks) * blockSize, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/kamackay/all/model"
 	"github.com/kamackay/all/unique"
 	"golang.org/x/sync/semaphore"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"os"
@@ -238,17 +239,26 @@ func getSize(entry os.DirEntry) int64 {
 }
 
 func ReadStart(path string, size int) (string, error) {
-	r, err := os.Open(path)
+	header, err := ReadHead(path, size)
 	if err != nil {
 		return "", err
 	}
+	return string(header), nil
+}
+
+// ReadHead reads at most size bytes from the start of the file
+func ReadHead(path string, size int) ([]byte, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer r.Close()
 	header := make([]byte, size)
-	_, err = r.Read(header)
-	if err != nil {
-		return "", err
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
 	}
-	return string(header), nil
+	return header[:n], nil
 }
 
 func PrintTime(info os.FileInfo) string {
@@ -0,0 +1,146 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// EXIF tags holding when a photo was taken
+	tagExifIFD          = 0x8769
+	tagDateTime         = 0x0132
+	tagDateTimeOriginal = 0x9003
+
+	exifTimeFormat = "2006:01:02 15:04:05"
+	// Only this much of a JPEG is searched for its EXIF block, it's always near the start
+	exifSearchLength = 256 << 10
+)
+
+// ImageSize reads the dimensions of a jpeg, png or gif from its header
+func ImageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// ImageTaken is when a jpeg or tiff photo was taken according to its EXIF data, zero if it doesn't say
+func ImageTaken(path string) time.Time {
+	head, err := ReadHead(path, exifSearchLength)
+	if err != nil {
+		return time.Time{}
+	}
+	tiff := head
+	if bytes.HasPrefix(head, []byte("\xff\xd8")) {
+		if tiff = jpegExif(head); tiff == nil {
+			return time.Time{}
+		}
+	}
+	return exifTaken(tiff)
+}
+
+// jpegExif finds the TIFF structure inside a JPEG's APP1 segment
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xd9 || marker == 0xda {
+			// End of image or start of the pixel data, there's no EXIF
+			return nil
+		}
+		// The length counts its own two bytes, anything shorter is corrupt
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 {
+			return nil
+		}
+		end := i + 2 + length
+		if end > len(data) {
+			end = len(data)
+		}
+		if segment := data[i+4 : end]; marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+// exifTaken reads DateTimeOriginal from the EXIF IFD, falling back to the DateTime of the main image
+func exifTaken(tiff []byte) time.Time {
+	if len(tiff) < 8 {
+		// Too short for the header and the offset of the first IFD
+		return time.Time{}
+	}
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(tiff, []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(tiff, []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return time.Time{}
+	}
+	r := bytes.NewReader(tiff)
+	ifd0 := readIFD(r, order, order.Uint32(tiff[4:]))
+	if offset, ok := ifd0[tagExifIFD]; ok {
+		exif := readIFD(r, order, offset)
+		if t := exifTime(tiff, exif[tagDateTimeOriginal]); !t.IsZero() {
+			return t
+		}
+	}
+	return exifTime(tiff, ifd0[tagDateTime])
+}
+
+// readIFD maps each tag in the directory at offset to its value field, which for dates is the offset of the text
+func readIFD(r *bytes.Reader, order binary.ByteOrder, offset uint32) map[uint16]uint32 {
+	entries := make(map[uint16]uint32)
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return entries
+	}
+	var count uint16
+	if err := binary.Read(r, order, &count); err != nil {
+		return entries
+	}
+	for i := 0; i < int(count); i++ {
+		var entry struct {
+			Tag   uint16
+			Type  uint16
+			Count uint32
+			Value uint32
+		}
+		if err := binary.Read(r, order, &entry); err != nil {
+			break
+		}
+		entries[entry.Tag] = entry.Value
+	}
+	return entries
+}
+
+func exifTime(tiff []byte, offset uint32) time.Time {
+	end := int(offset) + len(exifTimeFormat)
+	if offset == 0 || end > len(tiff) {
+		return time.Time{}
+	}
+	text := strings.TrimRight(string(tiff[offset:end]), "\x00 ")
+	t, err := time.ParseInLocation(exifTimeFormat, text, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
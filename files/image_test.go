@@ -0,0 +1,91 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tiffWithDate builds a TIFF header holding one date, in the EXIF IFD when exif is set or as the main image's DateTime
+func tiffWithDate(order binary.ByteOrder, date string, exif bool) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II*\x00")
+	} else {
+		buf.WriteString("MM\x00*")
+	}
+	write := func(v interface{}) { _ = binary.Write(&buf, order, v) }
+	entry := func(tag, kind uint16, count, value uint32) {
+		write(tag)
+		write(kind)
+		write(count)
+		write(value)
+	}
+	const ifdLength = 2 + 12 + 4
+	write(uint32(8))
+	write(uint16(1))
+	text := uint32(8 + ifdLength)
+	if exif {
+		text += ifdLength
+		entry(tagExifIFD, 4, 1, 8+ifdLength)
+		write(uint32(0))
+		write(uint16(1))
+		entry(tagDateTimeOriginal, 2, uint32(len(date)+1), text)
+	} else {
+		entry(tagDateTime, 2, uint32(len(date)+1), text)
+	}
+	write(uint32(0))
+	buf.WriteString(date + "\x00")
+	return buf.Bytes()
+}
+
+// jpegWith wraps an APP1 EXIF segment holding tiff in a minimal JPEG
+func jpegWith(tiff []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\xff\xd8")
+	buf.WriteString("\xff\xe0\x00\x07JFIF\x00")
+	buf.WriteString("\xff\xe1")
+	_ = binary.Write(&buf, binary.BigEndian, uint16(2+6+len(tiff)))
+	buf.WriteString("Exif\x00\x00")
+	buf.Write(tiff)
+	buf.WriteString("\xff\xda\x00\x02")
+	return buf.Bytes()
+}
+
+func TestImageTaken(t *testing.T) {
+	taken := time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	tests := []struct {
+		name string
+		data []byte
+		want time.Time
+	}{
+		{"jpeg little endian", jpegWith(tiffWithDate(binary.LittleEndian, "2021:03:04 05:06:07", true)), taken},
+		{"jpeg big endian", jpegWith(tiffWithDate(binary.BigEndian, "2021:03:04 05:06:07", true)), taken},
+		{"tiff", tiffWithDate(binary.LittleEndian, "2021:03:04 05:06:07", true), taken},
+		{"main image date", jpegWith(tiffWithDate(binary.BigEndian, "2021:03:04 05:06:07", false)), taken},
+		{"no exif", []byte("\xff\xd8\xff\xe0\x00\x07JFIF\x00\xff\xda\x00\x02"), time.Time{}},
+		{"bad date", jpegWith(tiffWithDate(binary.LittleEndian, "0000:00:00 00:00:00", true)), time.Time{}},
+		{"zero segment length", []byte("\xff\xd8\xff\xe1\x00\x00Exif\x00\x00II*\x00"), time.Time{}},
+		{"one segment length", []byte("\xff\xd8\xff\xe1\x00\x01Exif\x00\x00"), time.Time{}},
+		{"segment past the end", []byte("\xff\xd8\xff\xe1\xff\xffExif\x00\x00II*\x00\x08"), time.Time{}},
+		{"short tiff", []byte("II*\x00\x08"), time.Time{}},
+		{"tiff header only", []byte("MM\x00*"), time.Time{}},
+		{"ifd past the end", []byte("II*\x00\xff\xff\x00\x00"), time.Time{}},
+		{"empty exif", jpegWith(nil), time.Time{}},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".jpg")
+			if err := os.WriteFile(path, test.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := ImageTaken(path); !got.Equal(test.want) {
+				t.Errorf("ImageTaken = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
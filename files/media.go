@@ -0,0 +1,167 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+)
+
+type MediaType int
+
+const (
+	NotMedia MediaType = iota
+	Video
+	Audio
+	Image
+)
+
+func (t MediaType) String() string {
+	switch t {
+	case Video:
+		return "video"
+	case Audio:
+		return "audio"
+	case Image:
+		return "image"
+	default:
+		return "not media"
+	}
+}
+
+// Number of bytes read from the start of a file to recognise its format, MPEG-TS needs its second sync byte at 188
+const sniffLength = 512
+
+var mediaExtensions = map[string]MediaType{
+	".mp4":  Video,
+	".m4v":  Video,
+	".mov":  Video,
+	".mkv":  Video,
+	".webm": Video,
+	".avi":  Video,
+	".wmv":  Video,
+	".flv":  Video,
+	".mpg":  Video,
+	".mpeg": Video,
+	".ts":   Video,
+	".m2ts": Video,
+	".mts":  Video,
+	".3gp":  Video,
+	".ogv":  Video,
+	".vob":  Video,
+	".mp3":  Audio,
+	".m4a":  Audio,
+	".aac":  Audio,
+	".flac": Audio,
+	".wav":  Audio,
+	".ogg":  Audio,
+	".opus": Audio,
+	".wma":  Audio,
+	".jpg":  Image,
+	".jpeg": Image,
+	".png":  Image,
+	".gif":  Image,
+	".bmp":  Image,
+	".tif":  Image,
+	".tiff": Image,
+	".webp": Image,
+	".heic": Image,
+	".avif": Image,
+}
+
+// ftyp brands of ISO media files that aren't video
+var ftypBrands = map[string]MediaType{
+	"M4A ": Audio,
+	"M4B ": Audio,
+	"heic": Image,
+	"heix": Image,
+	"mif1": Image,
+	"msf1": Image,
+	"avif": Image,
+}
+
+// DetectMedia works out what kind of media a file is from its first bytes, falling back to its extension
+// for formats that can't be recognised that way. Files with a media extension whose contents are clearly
+// something else aren't media
+func DetectMedia(path string) MediaType {
+	byExtension := mediaExtensions[strings.ToLower(filepath.Ext(path))]
+	head, err := ReadHead(path, sniffLength)
+	if err != nil || len(head) == 0 {
+		return NotMedia
+	}
+	if t, ok := sniffMedia(head); ok {
+		if t == Audio && byExtension == Video {
+			// Ogg and ASF hold either, the extension says which
+			return Video
+		}
+		return t
+	}
+	if isText(head) {
+		return NotMedia
+	}
+	return byExtension
+}
+
+// sniffMedia recognises media formats by their magic bytes. Signatures that are plain letters are checked
+// together with the bytes after them, so a text file that happens to start with "BMW" or "ID3 tags" isn't media
+func sniffMedia(head []byte) (MediaType, bool) {
+	has := func(offset int, magic string) bool {
+		return len(head) >= offset+len(magic) && string(head[offset:offset+len(magic)]) == magic
+	}
+	switch {
+	case has(0, "\x00\x00") && has(4, "ftyp"): // After the size of the ftyp box, which is always small
+		if len(head) >= 12 {
+			if t, ok := ftypBrands[string(head[8:12])]; ok {
+				return t, true
+			}
+		}
+		return Video, true
+	case has(0, "\x1a\x45\xdf\xa3"): // Matroska and WebM
+		return Video, true
+	case has(0, "RIFF") && has(8, "AVI "):
+		return Video, true
+	case has(0, "RIFF") && has(8, "WAVE"):
+		return Audio, true
+	case has(0, "RIFF") && has(8, "WEBP"):
+		return Image, true
+	case has(0, "FLV\x01"):
+		return Video, true
+	case has(0, "\x00\x00\x01\xba"), has(0, "\x00\x00\x01\xb3"): // MPEG program stream and elementary video
+		return Video, true
+	case len(head) > 376 && head[0] == 0x47 && head[188] == 0x47 && head[376] == 0x47 && !isText(head):
+		// MPEG transport stream, 188 byte packets that each start with 0x47, which is also a G in text
+		return Video, true
+	case has(0, "\x30\x26\xb2\x75\x8e\x66\xcf\x11"): // ASF, used by wmv and wma
+		return Audio, true
+	case has(0, "OggS\x00"): // Followed by the version, which is always 0
+		return Audio, true
+	case has(0, "ID3") && len(head) >= 5 && head[3] >= 2 && head[3] <= 4 && head[4] != 0xff:
+		// Followed by the ID3v2 major version and revision
+		return Audio, true
+	case has(0, "fLaC") && len(head) >= 5 && head[4]&0x7f == 0: // The first metadata block is always STREAMINFO
+		return Audio, true
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 && head[1] != 0xff:
+		// MPEG audio frame sync
+		return Audio, true
+	case has(0, "\xff\xd8\xff"), has(0, "\x89PNG"), has(0, "GIF87a"), has(0, "GIF89a"), isBMP(head),
+		has(0, "II*\x00"), has(0, "MM\x00*"):
+		return Image, true
+	}
+	return NotMedia, false
+}
+
+// Sizes of the BMP info headers, which follow the 14 byte file header
+var bmpInfoSizes = map[uint32]bool{12: true, 16: true, 40: true, 52: true, 56: true, 64: true, 108: true, 124: true}
+
+// isBMP checks for "BM" followed by a file header with its reserved bytes zeroed and a known info header size
+func isBMP(head []byte) bool {
+	if len(head) < 18 || string(head[:2]) != "BM" || binary.LittleEndian.Uint32(head[6:10]) != 0 {
+		return false
+	}
+	return bmpInfoSizes[binary.LittleEndian.Uint32(head[14:18])]
+}
+
+// isText checks for the NUL bytes binary formats are full of
+func isText(head []byte) bool {
+	return bytes.IndexByte(head, 0) < 0
+}
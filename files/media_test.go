@@ -0,0 +1,76 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// transportStream is the start of an MPEG-TS file, a program association table and padding packets that each
+// begin with the sync byte
+func transportStream() string {
+	pat := "\x47\x40\x00\x10\x00\x00\xb0\x0d\x00\x01\xc1\x00\x00\x00\x01\xf0\x00"
+	pat += strings.Repeat("\xff", 188-len(pat))
+	return pat + strings.Repeat("\x47\x1f\xff\x10"+strings.Repeat("\xff", 184), 2)
+}
+
+func TestDetectMedia(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     MediaType
+	}{
+		{"movie.mp4", "\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2", Video},
+		{"song.m4a", "\x00\x00\x00\x1cftypM4A \x00\x00\x02\x00M4A isom", Audio},
+		{"photo.heic", "\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic", Image},
+		{"movie.bin", "\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01", Video},
+		{"clip.avi", "RIFF\x24\x00\x00\x00AVI LIST", Video},
+		{"sound.wav", "RIFF\x24\x00\x00\x00WAVEfmt ", Audio},
+		{"picture.webp", "RIFF\x24\x00\x00\x00WEBPVP8 ", Image},
+		{"clip.flv", "FLV\x01\x05\x00\x00\x00\x09", Video},
+		{"disc.vob", "\x00\x00\x01\xba\x44\x00\x04\x00", Video},
+		{"recording.m2ts", transportStream(), Video},
+		{"show.wmv", "\x30\x26\xb2\x75\x8e\x66\xcf\x11\xa6\xd9", Video},
+		{"song.wma", "\x30\x26\xb2\x75\x8e\x66\xcf\x11\xa6\xd9", Audio},
+		{"song.ogg", "OggS\x00\x02\x00\x00", Audio},
+		{"clip.ogv", "OggS\x00\x02\x00\x00", Video},
+		{"song.mp3", "ID3\x04\x00\x00\x00\x00\x00\x23", Audio},
+		{"song.bin", "\xff\xfb\x90\x64\x00", Audio},
+		{"song.flac", "fLaC\x80\x00\x00\x22", Audio},
+		{"photo.dat", "\xff\xd8\xff\xe0\x00\x10JFIF\x00", Image},
+		{"image.dat", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", Image},
+		{"anim.dat", "GIF89a\x01\x00\x01\x00", Image},
+		{"bitmap.dat", "BM\x46\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00\x28\x00\x00\x00", Image},
+		{"scan.dat", "II*\x00\x08\x00\x00\x00", Image},
+		{"scan.tif", "MM\x00*\x00\x00\x00\x08", Image},
+		// Unrecognised binary files go by their extension
+		{"movie.mov", "\x00\x00\x00\x08wide\x01\x02", Video},
+		{"data.bin", "\x00\x01\x02\x03", NotMedia},
+		// Text never counts as media, even when it starts like a signature or has a media extension
+		{"notes.txt", "BMW service history\n", NotMedia},
+		{"readme.md", "ID3 tags explained\n", NotMedia},
+		{"gif.txt", "GIF8 is how every GIF starts\n", NotMedia},
+		{"ogg.txt", "OggS pages\n", NotMedia},
+		{"flac.txt", "fLaC files are lossless\n", NotMedia},
+		{"boxes.txt", "The ftyp box comes first\n", NotMedia},
+		{"gs.txt", strings.Repeat("G"+strings.Repeat("g", 187), 3), NotMedia},
+		{"fake.mp4", "not really a video\n", NotMedia},
+		{"empty.mp4", "", NotMedia},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name)
+			if err := os.WriteFile(path, []byte(test.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectMedia(path); got != test.want {
+				t.Errorf("DetectMedia = %s, want %s", got, test.want)
+			}
+		})
+	}
+	if got := DetectMedia(filepath.Join(dir, "missing.mp4")); got != NotMedia {
+		t.Errorf("DetectMedia of a missing file = %s, want not media", got)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package files
+
+import "os"
+
+// Owner isn't tracked the same way on this platform
+func Owner(info os.FileInfo) string {
+	return "?"
+}
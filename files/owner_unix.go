@@ -0,0 +1,43 @@
+//go:build unix
+
+package files
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// Looking up names reads the passwd and group files, so remember them
+var (
+	namesMutex sync.Mutex
+	userNames  = make(map[string]string)
+	groupNames = make(map[string]string)
+)
+
+// Owner returns the user and group owning the file, falling back to the numeric ids when they can't be looked up
+func Owner(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "?"
+	}
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+	if _, ok := userNames[uid]; !ok {
+		userNames[uid] = uid
+		if u, err := user.LookupId(uid); err == nil {
+			userNames[uid] = u.Username
+		}
+	}
+	if _, ok := groupNames[gid]; !ok {
+		groupNames[gid] = gid
+		if g, err := user.LookupGroupId(gid); err == nil {
+			groupNames[gid] = g.Name
+		}
+	}
+	return userNames[uid] + ":" + groupNames[gid]
+}
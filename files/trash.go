@@ -0,0 +1,64 @@
+package files
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Trash moves path to the user's trash, ~/.Trash on macOS and the freedesktop.org trash everywhere else
+func Trash(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return fmt.Errorf("trash isn't supported on windows")
+	case "darwin":
+		dir := filepath.Join(home, ".Trash")
+		return Move(path, filepath.Join(dir, freeName(dir, filepath.Base(path))), func(int64) {})
+	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	trash := filepath.Join(data, "Trash")
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trash, dir), 0700); err != nil {
+			return err
+		}
+	}
+	name := freeName(filepath.Join(trash, "files"), filepath.Base(path))
+	// The info file says where it came from so file managers can restore it
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", (&url.URL{Path: path}).EscapedPath(),
+		time.Now().Format("2006-01-02T15:04:05"))
+	infoPath := filepath.Join(trash, "info", name+".trashinfo")
+	if err := os.WriteFile(infoPath, []byte(info), 0600); err != nil {
+		return err
+	}
+	if err := Move(path, filepath.Join(trash, "files", name), func(int64) {}); err != nil {
+		_ = os.Remove(infoPath)
+		return err
+	}
+	return nil
+}
+
+// freeName is name, or name with a number added if dir already has something called that
+func freeName(dir string, name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = name + "." + strconv.Itoa(i)
+	}
+}
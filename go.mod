@@ -3,23 +3,26 @@ module github.com/kamackay/all
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/alecthomas/kong v0.2.11
 	github.com/dustin/go-humanize v1.0.0
 	github.com/fatih/color v1.13.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/gosuri/uilive v0.0.4
+	github.com/mattn/go-runewidth v0.0.13
 	github.com/nsf/termbox-go v1.1.1
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	golang.org/x/sync v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/kamackay/godash v1.1.0
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
-	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/samber/lo v1.39.0
 	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
-	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
+	golang.org/x/sys v0.4.0 // indirect
 )
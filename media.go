@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kamackay/all/files"
+	"github.com/kamackay/all/l"
+	"github.com/kamackay/all/model"
+	"github.com/kamackay/all/utils"
+	"github.com/kamackay/all/video"
+	"github.com/kamackay/godash/parallel"
+)
+
+// resolutionFilter is a comparison like >=1080 against the shorter side of the picture
+type resolutionFilter struct {
+	op    string
+	value int
+}
+
+func parseResolution(expr string) (*resolutionFilter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	f := &resolutionFilter{op: "="}
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(expr, op) {
+			f.op = op
+			expr = expr[len(op):]
+			break
+		}
+	}
+	value, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(expr)), "p"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read resolution %q, expected something like >=1080", expr)
+	}
+	f.value = value
+	return f, nil
+}
+
+func (f *resolutionFilter) matches(resolution int) bool {
+	switch f.op {
+	case ">=":
+		return resolution >= f.value
+	case "<=":
+		return resolution <= f.value
+	case ">":
+		return resolution > f.value
+	case "<":
+		return resolution < f.value
+	default:
+		return resolution == f.value
+	}
+}
+
+// mediaInfo reads what the listing shows about a file, or nil if it isn't media
+func mediaInfo(bean *model.FileBean, probes *video.Cache) *model.MediaInfo {
+	if bean.IsDir() {
+		return nil
+	}
+	t := files.DetectMedia(bean.Name)
+	if t == files.NotMedia {
+		return nil
+	}
+	info := &model.MediaInfo{Name: bean.Name, Type: t.String(), Size: bean.Size, Modified: bean.LastModified()}
+	if t == files.Image {
+		if taken := files.ImageTaken(bean.Name); !taken.IsZero() {
+			info.Taken = &taken
+		}
+		width, height, err := files.ImageSize(bean.Name)
+		if err == nil {
+			info.Width, info.Height = width, height
+			return info
+		}
+		// Formats the standard library can't decode still have a size ffprobe can read
+	}
+	probe, err := probes.Probe(bean.Name, bean.Size, bean.LastModified())
+	if err != nil {
+		l.Error(err)
+		return info
+	}
+	if t != files.Image {
+		info.Duration, _ = probe.Duration()
+		info.BitRate = probe.Format.Bits()
+	}
+	if stream := probe.VideoStream(); stream != nil && t != files.Audio {
+		info.Width, info.Height, info.Codec = stream.Width, stream.Height, stream.CodecName
+	} else if stream := probe.AudioStream(); stream != nil {
+		info.Codec = stream.CodecName
+	}
+	return info
+}
+
+func sortMedia(list []*model.MediaInfo, by string, reverse bool) {
+	var less func(i, j int) bool
+	switch by {
+	case "none":
+		return
+	case "size":
+		less = func(i, j int) bool { return list[i].Size < list[j].Size }
+	case "time", "modified":
+		less = func(i, j int) bool { return list[i].Modified.Before(list[j].Modified) }
+	case "duration":
+		less = func(i, j int) bool { return list[i].Duration < list[j].Duration }
+	case "resolution":
+		less = func(i, j int) bool { return list[i].Resolution() < list[j].Resolution() }
+	default:
+		less = func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) }
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if reverse {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+}
+
+// formatDuration shows seconds as h:mm:ss, or m:ss under an hour
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// listMedia prints the video, audio and image files in the list with their details, filtered by the --media flags
+func listMedia(fileList []*model.FileBean, opts model.Opts) {
+	red := color.New(color.FgRed)
+	resolution, err := parseResolution(opts.Resolution)
+	if err != nil {
+		red.Printf("%+v\n", err)
+		return
+	}
+	probes, err := video.LoadCache(video.FFProbe{})
+	if err != nil {
+		l.Error(err)
+	}
+	unique := utils.Unique(fileList, func(file *model.FileBean) string { return file.Name })
+	results, err := parallel.Map(unique, runtime.NumCPU(), func(f *model.FileBean) *model.MediaInfo {
+		return mediaInfo(f, probes)
+	})
+	if err := probes.Save(); err != nil {
+		l.Error(err)
+	}
+	if err != nil {
+		red.Printf("Error in processing files: %+v\n", err)
+		return
+	}
+	list := make([]*model.MediaInfo, 0, len(results))
+	for _, m := range results {
+		switch {
+		case m == nil:
+		case opts.MinDuration > 0 && m.Duration < opts.MinDuration.Seconds():
+		case opts.MaxDuration > 0 && (m.Duration == 0 || m.Duration > opts.MaxDuration.Seconds()):
+		case resolution != nil && (m.Resolution() == 0 || !resolution.matches(m.Resolution())):
+		default:
+			list = append(list, m)
+		}
+	}
+	sortMedia(list, opts.Sort, opts.Reverse)
+	switch opts.Output {
+	case "json":
+		err = writeMediaJSON(os.Stdout, list)
+	case "csv":
+		err = writeMediaCSV(os.Stdout, list)
+	default:
+		writeMediaText(os.Stdout, list, opts.Humanize)
+	}
+	if err != nil {
+		red.Printf("Could not write listing: %+v\n", err)
+	}
+}
+
+func writeMediaText(w io.Writer, list []*model.MediaInfo, humanize bool) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var size uint64
+	var duration float64
+	for _, m := range list {
+		size += m.Size
+		duration += m.Duration
+		length, picture, bitRate, taken := "", "", "", ""
+		if m.Duration > 0 {
+			length = formatDuration(m.Duration)
+		}
+		if m.Width > 0 {
+			picture = fmt.Sprintf("%dx%d", m.Width, m.Height)
+		}
+		if m.BitRate > 0 {
+			bitRate = formatBitRate(m.BitRate)
+		}
+		if m.Taken != nil {
+			taken = m.Taken.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.Type, length, picture, m.Codec, bitRate, taken,
+			utils.FormatSize(m.Size, humanize), m.Name)
+	}
+	table.Flush()
+	fmt.Fprintf(w, "%d files, %s, %s long\n", len(list), utils.HumanizeBytes(size), formatDuration(duration))
+}
+
+func writeMediaJSON(w io.Writer, list []*model.MediaInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+func writeMediaCSV(w io.Writer, list []*model.MediaInfo) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "type", "size", "modified", "duration", "width", "height", "codec", "bit_rate",
+		"taken"})
+	if err != nil {
+		return err
+	}
+	for _, m := range list {
+		taken := ""
+		if m.Taken != nil {
+			taken = m.Taken.Format(time.RFC3339)
+		}
+		err := writer.Write([]string{
+			m.Name,
+			m.Type,
+			strconv.FormatUint(m.Size, 10),
+			m.Modified.Format(time.RFC3339),
+			strconv.FormatFloat(m.Duration, 'f', 3, 64),
+			strconv.Itoa(m.Width),
+			strconv.Itoa(m.Height),
+			m.Codec,
+			strconv.FormatFloat(m.BitRate, 'f', 0, 64),
+			taken,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// MediaInfo is a row of the --media listing, fields that don't apply to the file's type are left empty
+type MediaInfo struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Size     uint64    `json:"size"`
+	Modified time.Time `json:"modified"`
+	// Length in seconds
+	Duration float64 `json:"duration,omitempty"`
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	Codec    string  `json:"codec,omitempty"`
+	// Bits per second of the whole file
+	BitRate float64 `json:"bit_rate,omitempty"`
+	// When a photo was taken according to its EXIF data
+	Taken *time.Time `json:"taken,omitempty"`
+}
+
+// Resolution is the shorter side of the picture, so portrait and landscape 1080p are both 1080
+func (m *MediaInfo) Resolution() int {
+	if m.Width < m.Height {
+		return m.Width
+	}
+	return m.Height
+}
@@ -5,6 +5,11 @@ type SortType int
 const (
 	SortSize SortType = iota
 	SortName
+	SortModified
+	SortChildren
+	SortExtension
+	SortDirsFirst
+	sortTypeCount
 )
 
 type LoadingInfo struct {
@@ -14,10 +19,6 @@ type LoadingInfo struct {
 	Render  bool
 }
 
-type FileMode struct {
-	Contents string
-}
-
 type Confirmation struct {
 	Message string
 	Action  func()
@@ -31,7 +32,27 @@ func SortTypeName(sortType SortType) string {
 		return "name"
 	case SortSize:
 		return "filesize"
+	case SortModified:
+		return "modified time"
+	case SortChildren:
+		return "child count"
+	case SortExtension:
+		return "extension"
+	case SortDirsFirst:
+		return "directories first"
 	default:
 		return "idk, randomly" // Again, shouldn't be possible
 	}
 }
+
+// NextSortType is the sort mode after the given one, wrapping back around to the first
+func NextSortType(sortType SortType) SortType {
+	return (sortType + 1) % sortTypeCount
+}
+
+// Operation is a long running file operation in the browser, like copying a large folder
+type Operation struct {
+	Label string
+	Done  int64
+	Total int64
+}
@@ -1,25 +1,47 @@
 package model
 
+import "time"
+
 type Opts struct {
-	Version    bool   `help:"Print Version"`
-	Browser    bool   `short:"b" help:"Run Browser"`
-	VideoScore bool   `help:"Get Video Compression Score"`
-	RmEmpty    bool   `help:"Delete Empty Directories"`
-	Verbose    bool   `short:"v" help:"Verbose"`
-	Quiet      bool   `short:"q" help:"Only Log file info, exclude logs like time to process"`
-	Directory  string `arg:"d" help:"Directory" default:"."`
-	Sort       string `short:"S" enum:"size,time,modified,name,none" help:"Sorting options. One of size, time (alias of modified), modified, name, none" default:"name"`
-	Reverse    bool   `short:"r" help:"Reverse order of the list"`
-	Humanize   bool   `short:"z" help:"Humanize File Sizes"`
-	NamesOnly  bool   `short:"n" help:"Only Show filenames"`
-	NoEmpty    bool   `short:"e" help:"Don't show empty files and folders'"`
-	Large      bool   `short:"G" help:"Only print files over 1 GB"`
-	MinSize    uint64 `default:"0" help:"Only show files larger than or equal to this (value provided in Bytes)"`
-	MaxSize    uint64 `default:"18446744073709551615" help:"Only show files smaller than or equal to this (value provided in Bytes)"`
-	FirstOnly  bool   `short:"f" help:"Only show the first level of the filetree"`
-	FilesOnly  bool   `short:"F" help:"Only Print Files, Exclude all directories"`
-	Regex      string `short:"r" help:"Search for files that match this regex in it's entirety (Search does a substring search)"`
-	Search     string `short:"s" help:"Search all files in this folder for this text" default:""`
-	NoCase     bool   `short:"i" help:"Use Case Insensitivity for Search"`
-	Yes        bool   `short:"y" help:"Answer yes to all prompts"`
+	Version     bool          `help:"Print Version"`
+	Browser     bool          `short:"b" help:"Run Browser"`
+	Compare     string        `help:"With -b, compare this directory side by side with Directory"`
+	VideoScore  bool          `help:"Get Video Compression Score"`
+	Transcode   bool          `help:"With --video-score, re-encode videos scoring over --threshold with ffmpeg, worst first"`
+	Threshold   float64       `default:"20" help:"Video score a file has to be over to be transcoded"`
+	Preset      string        `help:"ffmpeg preset for --transcode, one of hevc, h264, av1 or a name from [video.presets] in config.toml"`
+	Jobs        int           `short:"j" default:"1" help:"Number of ffmpeg processes --transcode runs at once"`
+	DryRun      bool          `help:"Show what would be done without changing anything"`
+	Media       bool          `help:"List video, audio and image files with their duration, resolution, codec, bit rate and date taken"`
+	MinDuration time.Duration `help:"With --media, only list files at least this long, like 10m"`
+	MaxDuration time.Duration `help:"With --media, only list files at most this long"`
+	Resolution  string        `help:"With --media, only list files whose picture matches, like >=1080 or <720. Compared to the shorter side"`
+	RmEmpty     RmEmpty       `help:"Delete Empty Directories, including ones that only hold empty directories and junk files. --rm-empty=files,dirs deletes zero byte files too"`
+	Verbose     bool          `short:"v" help:"Verbose"`
+	Quiet       bool          `short:"q" help:"Only Log file info, exclude logs like time to process"`
+	Directory   string        `arg:"d" help:"Directory" default:"."`
+	Sort        string        `short:"S" enum:"size,time,modified,name,none,score,recover,duration,resolution" help:"Sorting options. One of size, time (alias of modified), modified, name, none. With --video-score one of score, recover (default) or size. With --media also duration or resolution" default:"name"`
+	Output      string        `enum:"text,json,csv" default:"text" help:"Format of the --video-score report and --media listing, one of text, json or csv"`
+	Reverse     bool          `short:"r" help:"Reverse order of the list"`
+	Humanize    bool          `short:"z" help:"Humanize File Sizes"`
+	NamesOnly   bool          `short:"n" help:"Only Show filenames"`
+	NoEmpty     bool          `short:"e" help:"Don't show empty files and folders'"`
+	Large       bool          `short:"G" help:"Only print files over 1 GB"`
+	MinSize     uint64        `default:"0" help:"Only show files larger than or equal to this (value provided in Bytes)"`
+	MaxSize     uint64        `default:"18446744073709551615" help:"Only show files smaller than or equal to this (value provided in Bytes)"`
+	FirstOnly   bool          `short:"f" help:"Only show the first level of the filetree"`
+	FilesOnly   bool          `short:"F" help:"Only Print Files, Exclude all directories"`
+	Regex       string        `short:"r" help:"Search for files that match this regex in it's entirety (Search does a substring search)"`
+	Search      string        `short:"s" help:"Search all files in this folder for this text" default:""`
+	NoCase      bool          `short:"i" help:"Use Case Insensitivity for Search"`
+	Yes         bool          `short:"y" help:"Answer yes to all prompts"`
+}
+
+// CleanOpts are the flags of `all clean`, parsed separately since kong can't mix the directory argument with commands
+type CleanOpts struct {
+	Rules     string `short:"R" required:"" type:"existingfile" help:"YAML file of cleanup rules"`
+	Directory string `arg:"" optional:"" help:"Directory to clean" default:"."`
+	DryRun    bool   `help:"Show what each rule matches without changing anything"`
+	Verbose   bool   `short:"v" help:"List every matched entry, not just the totals"`
+	Yes       bool   `short:"y" help:"Answer yes to all prompts"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// RmEmpty is what --rm-empty removes. On its own the flag means empty directories, --rm-empty=files,dirs picks
+type RmEmpty struct {
+	Files bool
+	Dirs  bool
+}
+
+func (r *RmEmpty) Decode(ctx *kong.DecodeContext) error {
+	if ctx.Scan.Peek().Type != kong.FlagValueToken {
+		r.Dirs = true
+		return nil
+	}
+	value := fmt.Sprintf("%v", ctx.Scan.Pop().Value)
+	for _, kind := range strings.Split(value, ",") {
+		switch strings.TrimSpace(strings.ToLower(kind)) {
+		case "files":
+			r.Files = true
+		case "dirs":
+			r.Dirs = true
+		default:
+			return fmt.Errorf("expected files, dirs or files,dirs but got %q", value)
+		}
+	}
+	return nil
+}
+
+// IsBool lets the flag be given without a value
+func (r *RmEmpty) IsBool() bool {
+	return true
+}
+
+func (r RmEmpty) Enabled() bool {
+	return r.Files || r.Dirs
+}
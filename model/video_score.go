@@ -1,10 +1,17 @@
 package model
 
 type VideoScore struct {
-	Name         string
-	Score        float64
-	Size         uint64
-	CouldRecover int64
+	Name         string  `json:"name"`
+	Score        float64 `json:"score"`
+	Size         uint64  `json:"size"`
+	CouldRecover int64   `json:"could_recover"`
+	Codec        string  `json:"codec"`
+	Width        int     `json:"width"`
+	Height       int     `json:"height"`
+	// Length in seconds
+	Duration float64 `json:"duration"`
+	// Bits per second of the video stream
+	BitRate float64 `json:"bit_rate"`
 }
 
 func NewScore(score float64, bean *FileBean, couldRecover int64) *VideoScore {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/kamackay/all/model"
+)
+
+// sortScores orders the video report ascending so the worst files end up at the bottom of the terminal,
+// anything other than score or size sorts by the bytes that could be recovered
+func sortScores(scores []*model.VideoScore, by string, reverse bool) {
+	less := func(i, j int) bool {
+		return scores[i].CouldRecover < scores[j].CouldRecover
+	}
+	switch by {
+	case "score":
+		less = func(i, j int) bool {
+			return scores[i].Score < scores[j].Score
+		}
+	case "size":
+		less = func(i, j int) bool {
+			return scores[i].Size < scores[j].Size
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		if reverse {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+}
+
+func scoreTotals(scores []*model.VideoScore) (uint64, uint64) {
+	var size, couldRecover uint64
+	for _, s := range scores {
+		size += s.Size
+		couldRecover += uint64(s.CouldRecover)
+	}
+	return size, couldRecover
+}
+
+func formatBitRate(bits float64) string {
+	return fmt.Sprintf("%.1f Mbit/s", bits/1000000)
+}
+
+type scoreReport struct {
+	Videos            []*model.VideoScore `json:"videos"`
+	TotalSize         uint64              `json:"total_size"`
+	TotalCouldRecover uint64              `json:"total_could_recover"`
+}
+
+func writeScoresJSON(w io.Writer, scores []*model.VideoScore) error {
+	size, couldRecover := scoreTotals(scores)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(scoreReport{Videos: scores, TotalSize: size, TotalCouldRecover: couldRecover})
+}
+
+// writeScoresCSV writes a row per video followed by a total row with an empty name
+func writeScoresCSV(w io.Writer, scores []*model.VideoScore) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "score", "size", "could_recover", "codec", "width", "height", "duration",
+		"bit_rate"})
+	if err != nil {
+		return err
+	}
+	for _, s := range scores {
+		err := writer.Write([]string{
+			s.Name,
+			strconv.FormatFloat(s.Score, 'f', 2, 64),
+			strconv.FormatUint(s.Size, 10),
+			strconv.FormatInt(s.CouldRecover, 10),
+			s.Codec,
+			strconv.Itoa(s.Width),
+			strconv.Itoa(s.Height),
+			strconv.FormatFloat(s.Duration, 'f', 3, 64),
+			strconv.FormatFloat(s.BitRate, 'f', 0, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	size, couldRecover := scoreTotals(scores)
+	err = writer.Write([]string{"", "", strconv.FormatUint(size, 10), strconv.FormatUint(couldRecover, 10),
+		"", "", "", "", ""})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
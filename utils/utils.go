@@ -2,23 +2,17 @@ package utils
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"os"
-	"os/exec"
 	"regexp"
-	"strconv"
 	"strings"
-
-	"github.com/kamackay/all/model"
 )
 
 const (
-	Space                = " "
-	TheoreticalBestScore = 10
+	Space = " "
 )
 
 func Spaces(index int) string {
@@ -76,102 +70,6 @@ func NilCheckElse(obj interface{}, action func(), elseAction func()) {
 	}
 }
 
-type Json = map[string]interface{}
-
-func GetVideoScore(bean *model.FileBean) (float64, error) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered in f", r)
-		}
-	}()
-	out, err := exec.Command("ffprobe", bean.Name, "-show_streams", "-show_format", "-print_format", "json").Output()
-	//fmt.Println(string(out))
-	var output Json
-	err = json.Unmarshal(out, &output)
-	if err != nil {
-		return 0, err
-	}
-	if val, ok := output["format"]; ok {
-		if duration, ok := val.(Json)["duration"]; ok {
-			durationString := duration.(string)
-			duration, err := strconv.ParseFloat(durationString, 64)
-			if err != nil {
-				return 0, err
-			}
-			if istreamList, ok := output["streams"]; ok {
-				streamList := istreamList.([]interface{})
-				if len(streamList) == 0 {
-					return 0, nil
-				}
-				firstStream := streamList[0].(Json)
-				if !MapContains(firstStream, "height") || !MapContains(firstStream, "width") {
-					// Wasn't a height and width, so skip
-					return 0, nil
-				}
-				height := firstStream["height"].(float64)
-				width := firstStream["width"].(float64)
-				numPixels := duration * height * width
-				return (float64(bean.Size) / numPixels) * 100, nil // Bytes per pixel
-			} else {
-				return 0, nil
-			}
-		} else {
-			return 0, nil
-		}
-	} else {
-		return 0, nil
-	}
-}
-
-func GetPotentialBytesToCompress(bean *model.FileBean) int64 {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered in GetPotentialBytesToCompress", r)
-		}
-	}()
-	out, err := exec.Command("ffprobe", bean.Name, "-show_streams", "-show_format", "-print_format", "json").Output()
-	//fmt.Println(string(out))
-	var output Json
-	err = json.Unmarshal(out, &output)
-	if err != nil {
-		return 0
-	}
-	if val, ok := output["format"]; ok {
-		if duration, ok := val.(Json)["duration"]; ok {
-			durationString := duration.(string)
-			duration, err := strconv.ParseFloat(durationString, 64)
-			if err != nil {
-				return 0
-			}
-			if istreamList, ok := output["streams"]; ok {
-				streamList := istreamList.([]interface{})
-				if len(streamList) == 0 {
-					return 0
-				}
-				firstStream := streamList[0].(Json)
-				if !MapContains(firstStream, "height") || !MapContains(firstStream, "width") {
-					// Wasn't a height and width, so skip
-					return 0
-				}
-				height := firstStream["height"].(float64)
-				width := firstStream["width"].(float64)
-				numPixels := duration * height * width
-				idealSize := (TheoreticalBestScore * numPixels) / 100
-				if float64(bean.Size) <= idealSize {
-					return 0
-				}
-				return int64(float64(bean.Size) - idealSize)
-			} else {
-				return 0
-			}
-		} else {
-			return 0
-		}
-	} else {
-		return 0
-	}
-}
-
 func AskForConfirmation(s string) bool {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -226,8 +124,3 @@ func Unique[T any, K comparable](slice []T, accessor func(T) K) []T {
 	}
 	return list
 }
-
-func MapContains[K comparable, T any](m map[K]T, key K) bool {
-	_, ok := m[key]
-	return ok
-}
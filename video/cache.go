@@ -0,0 +1,120 @@
+package video
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kamackay/all/config"
+)
+
+const (
+	cacheFile = "probe_cache.json"
+	// Bumped whenever Probe gains fields, so older entries are probed again
+	probeVersion = 2
+)
+
+type cacheEntry struct {
+	Version  int       `json:"version"`
+	Size     uint64    `json:"size"`
+	Modified time.Time `json:"modified"`
+	Probe    *Probe    `json:"probe"`
+}
+
+// Cache keeps ffprobe results between runs, an entry is only used while the file's size and modified time still match
+type Cache struct {
+	prober  Prober
+	mutex   sync.Mutex
+	path    string
+	entries map[string]cacheEntry
+	// Paths looked up since the cache was loaded, these are known to be current
+	used  map[string]bool
+	dirty bool
+}
+
+// NewCache is an empty cache in front of prober that's never saved
+func NewCache(prober Prober) *Cache {
+	return &Cache{prober: prober, entries: make(map[string]cacheEntry), used: make(map[string]bool)}
+}
+
+// LoadCache reads the cache from the config directory, a missing or unreadable cache starts out empty
+func LoadCache(prober Prober) (*Cache, error) {
+	c := NewCache(prober)
+	dir, err := config.Dir()
+	if err != nil {
+		return c, err
+	}
+	c.path = filepath.Join(dir, cacheFile)
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]cacheEntry)
+		return c, err
+	}
+	return c, nil
+}
+
+// Probe returns the cached result for the file, calling ffprobe if the file is new or has changed
+func (c *Cache) Probe(path string, size uint64, modified time.Time) (*Probe, error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[path]
+	c.used[path] = true
+	c.mutex.Unlock()
+	if ok && entry.Version == probeVersion && entry.Size == size && entry.Modified.Equal(modified) {
+		return entry.Probe, nil
+	}
+	probe, err := c.prober.Probe(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[path] = cacheEntry{Version: probeVersion, Size: size, Modified: modified, Probe: probe}
+	c.dirty = true
+	return probe, nil
+}
+
+// Save writes the cache back if anything was probed or pruned since it was loaded
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.prune()
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// prune drops entries that can't be used again: files that were deleted, moved or changed since they were probed,
+// and results from an older probeVersion. Entries looked up this run are current and skip the check
+func (c *Cache) prune() {
+	for path, entry := range c.entries {
+		if c.used[path] {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil && entry.Version == probeVersion && entry.Size == uint64(info.Size()) &&
+			entry.Modified.Equal(info.ModTime()) {
+			continue
+		}
+		delete(c.entries, path)
+		c.dirty = true
+	}
+}
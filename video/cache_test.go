@@ -0,0 +1,159 @@
+package video_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamackay/all/config"
+	"github.com/kamackay/all/video"
+)
+
+func TestCacheProbesOncePerVersionOfAFile(t *testing.T) {
+	prober := newFixtureProber()
+	cache := video.NewCache(prober)
+	modified := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	probe := func(size uint64, modified time.Time) {
+		t.Helper()
+		if _, err := cache.Probe("h264_1080p.mp4", size, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	probe(201600000, modified)
+	probe(201600000, modified)
+	if calls := prober.Calls("h264_1080p.mp4"); calls != 1 {
+		t.Fatalf("probed %d times, want once", calls)
+	}
+	probe(201600001, modified)
+	probe(201600001, modified.Add(time.Second))
+	if calls := prober.Calls("h264_1080p.mp4"); calls != 3 {
+		t.Fatalf("probed %d times, want again for the new size and again for the new modified time", calls)
+	}
+}
+
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	prober := newFixtureProber()
+	cache := video.NewCache(prober)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Probe("malformed.mp4", 1000, time.Time{}); err == nil {
+			t.Fatal("malformed output should fail")
+		}
+	}
+	if calls := prober.Calls("malformed.mp4"); calls != 2 {
+		t.Fatalf("probed %d times, failures should be tried again", calls)
+	}
+}
+
+func TestCacheSavesBetweenRuns(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	modified := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	first := newFixtureProber()
+	cache, err := video.LoadCache(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Probe("cover_art.mkv", 10000000000, modified); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := newFixtureProber()
+	cache, err = video.LoadCache(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe, err := cache.Probe("cover_art.mkv", 10000000000, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := second.Calls("cover_art.mkv"); calls != 0 {
+		t.Fatalf("probed %d times, want the saved result", calls)
+	}
+	if stream := probe.VideoStream(); stream == nil || stream.CodecName != "hevc" {
+		t.Fatalf("saved probe lost its video stream: %+v", stream)
+	}
+}
+
+func TestCacheDropsEntriesForFilesThatChanged(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	paths := make(map[string]string)
+	for _, name := range []string{"kept", "deleted", "changed"} {
+		paths[name] = filepath.Join(dir, name, "h264_1080p.mp4")
+		if err := os.MkdirAll(filepath.Dir(paths[name]), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(paths[name], []byte("video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	probeAll := func(cache *video.Cache, names ...string) {
+		t.Helper()
+		for _, name := range names {
+			info, err := os.Stat(paths[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cache.Probe(paths[name], uint64(info.Size()), info.ModTime()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cache, err := video.LoadCache(newFixtureProber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	probeAll(cache, "kept", "deleted", "changed")
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(paths["deleted"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths["changed"], []byte("re-encoded video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A run that doesn't look at any of them still clears out the dead entries
+	cache, err = video.LoadCache(newFixtureProber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err = config.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "probe_cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved[paths["kept"]]; !ok || len(saved) != 1 {
+		t.Fatalf("saved %d entries, want only %s: %s", len(saved), paths["kept"], data)
+	}
+
+	prober := newFixtureProber()
+	cache, err = video.LoadCache(prober)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probeAll(cache, "kept")
+	if calls := prober.Calls(paths["kept"]); calls != 0 {
+		t.Fatalf("probed %d times, the unchanged file should still be cached", calls)
+	}
+}
@@ -0,0 +1,45 @@
+package video_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kamackay/all/video"
+)
+
+// fixtureProber is a Prober that reads saved ffprobe output instead of running it, so scoring can be checked
+// without ffmpeg installed. The output for a file is the json file named after it in dir, movie.mkv is read
+// from movie.mkv.json, and a file without a fixture fails the way ffprobe does on a file it can't read
+type fixtureProber struct {
+	dir   string
+	mutex sync.Mutex
+	// Number of times each file was probed
+	calls map[string]int
+}
+
+func newFixtureProber() *fixtureProber {
+	return &fixtureProber{dir: "testdata", calls: make(map[string]int)}
+}
+
+func (f *fixtureProber) Probe(path string) (*video.Probe, error) {
+	f.mutex.Lock()
+	f.calls[path]++
+	f.mutex.Unlock()
+	data, err := os.ReadFile(filepath.Join(f.dir, filepath.Base(path)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe %s: Invalid data found when processing input", path)
+	}
+	probe, err := video.ParseProbe(data)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	return probe, nil
+}
+
+func (f *fixtureProber) Calls(path string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.calls[path]
+}
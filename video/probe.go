@@ -0,0 +1,152 @@
+package video
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Probe is the part of ffprobe's json output the video score uses
+type Probe struct {
+	Streams []Stream `json:"streams"`
+	Format  Format   `json:"format"`
+}
+
+type Stream struct {
+	Index        int               `json:"index"`
+	CodecType    string            `json:"codec_type"`
+	CodecName    string            `json:"codec_name"`
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	BitRate      string            `json:"bit_rate"`
+	AvgFrameRate string            `json:"avg_frame_rate"`
+	RFrameRate   string            `json:"r_frame_rate"`
+	Disposition  Disposition       `json:"disposition"`
+	Tags         map[string]string `json:"tags"`
+}
+
+type Disposition struct {
+	// Set on cover art, which ffprobe lists as a single frame video stream
+	AttachedPic int `json:"attached_pic"`
+}
+
+type Format struct {
+	FormatName string `json:"format_name"`
+	Duration   string `json:"duration"`
+	Size       string `json:"size"`
+	BitRate    string `json:"bit_rate"`
+}
+
+// Prober reads the streams and format of a media file
+type Prober interface {
+	Probe(path string) (*Probe, error)
+}
+
+// FFProbe is the Prober that calls the ffprobe binary
+type FFProbe struct{}
+
+func (FFProbe) Probe(path string) (*Probe, error) {
+	out, err := exec.Command("ffprobe", "-v", "error", "-show_streams", "-show_format",
+		"-print_format", "json", path).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("ffprobe %s: %s", path, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	probe, err := ParseProbe(out)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	return probe, nil
+}
+
+// ParseProbe reads the json ffprobe prints with -print_format json
+func ParseProbe(data []byte) (*Probe, error) {
+	var probe Probe
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	return &probe, nil
+}
+
+// VideoStream is the main picture of the file, the largest video stream that isn't cover art, or nil if there isn't one
+func (p *Probe) VideoStream() *Stream {
+	var best *Stream
+	for i := range p.Streams {
+		s := &p.Streams[i]
+		if s.CodecType != "video" || s.Disposition.AttachedPic == 1 || s.Width <= 0 || s.Height <= 0 {
+			continue
+		}
+		if best == nil || s.Width*s.Height > best.Width*best.Height {
+			best = s
+		}
+	}
+	return best
+}
+
+// AudioStream is the first audio stream, or nil if there isn't one
+func (p *Probe) AudioStream() *Stream {
+	for i := range p.Streams {
+		if s := &p.Streams[i]; s.CodecType == "audio" {
+			return s
+		}
+	}
+	return nil
+}
+
+// Bits is the bit rate of the whole file in bits per second, or 0 if ffprobe doesn't know it
+func (f Format) Bits() float64 {
+	bits, err := strconv.ParseFloat(f.BitRate, 64)
+	if err != nil {
+		return 0
+	}
+	return bits
+}
+
+// Duration is the length of the file in seconds
+func (p *Probe) Duration() (float64, error) {
+	if p.Format.Duration == "" {
+		return 0, fmt.Errorf("no duration")
+	}
+	return strconv.ParseFloat(p.Format.Duration, 64)
+}
+
+// Bits is the stream's bit rate in bits per second, or 0 if ffprobe doesn't know it.
+// Matroska only has it in the BPS tag mkvmerge writes
+func (s *Stream) Bits() float64 {
+	for _, value := range []string{s.BitRate, s.Tags["BPS"], s.Tags["BPS-eng"]} {
+		if bits, err := strconv.ParseFloat(value, 64); err == nil && bits > 0 {
+			return bits
+		}
+	}
+	return 0
+}
+
+// FrameRate is the stream's average frames per second, ffprobe gives it as a fraction like 30000/1001
+func (s *Stream) FrameRate() float64 {
+	for _, value := range []string{s.AvgFrameRate, s.RFrameRate} {
+		if rate := parseFraction(value); rate > 0 {
+			return rate
+		}
+	}
+	return 0
+}
+
+func parseFraction(value string) float64 {
+	parts := strings.SplitN(value, "/", 2)
+	numerator, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0
+	}
+	if len(parts) == 1 {
+		return numerator
+	}
+	denominator, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
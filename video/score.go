@@ -0,0 +1,116 @@
+package video
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TheoreticalBestScore is the score of a file whose video is exactly at its codec's target bit rate
+	TheoreticalBestScore = 10
+
+	// Targets are given for this many pixels per second, 1080p at 30fps, and scaled to each file's resolution and frame rate
+	referencePixelRate = 1920 * 1080 * 30
+	// Used when ffprobe doesn't know the frame rate
+	defaultFrameRate = 30
+	defaultCodec     = "default"
+)
+
+// DefaultTargets are the video bit rates in Mbit/s a 1080p 30fps file is expected to need with each codec
+var DefaultTargets = Targets{
+	"h264":       8,
+	"hevc":       4,
+	"vp9":        4,
+	"av1":        3,
+	"mpeg4":      10,
+	"mpeg2video": 15,
+	"vc1":        10,
+	"prores":     120,
+	"dnxhd":      120,
+	defaultCodec: 8,
+}
+
+// Targets maps codec names to bit rates in Mbit/s at 1080p 30fps
+type Targets map[string]float64
+
+// NewTargets is the defaults with the configured codecs replaced
+func NewTargets(config map[string]float64) Targets {
+	targets := make(Targets, len(DefaultTargets)+len(config))
+	for codec, mbits := range DefaultTargets {
+		targets[codec] = mbits
+	}
+	for codec, mbits := range config {
+		if mbits > 0 {
+			targets[strings.ToLower(codec)] = mbits
+		}
+	}
+	return targets
+}
+
+// bits is the target in bits per second for a stream of the given codec, size and frame rate
+func (t Targets) bits(codec string, width, height int, fps float64) float64 {
+	mbits, ok := t[strings.ToLower(codec)]
+	if !ok {
+		mbits = t[defaultCodec]
+	}
+	return mbits * 1000000 * float64(width*height) * fps / referencePixelRate
+}
+
+// Result is a file's score along with the details of the stream it was worked out from
+type Result struct {
+	Score float64
+	// Bytes re-encoding the video at the target would save
+	CouldRecover int64
+	Codec        string
+	Width        int
+	Height       int
+	// Length in seconds
+	Duration float64
+	// Bits per second of the video stream alone
+	BitRate float64
+}
+
+// Score compares the bit rate of a file's video stream to the target for its codec, resolution and frame rate.
+// TheoreticalBestScore means it's right on target and twice that means it uses twice the bits it needs.
+// Audio and subtitle streams are taken out first since re-encoding the video doesn't shrink them
+func Score(probe *Probe, size uint64, targets Targets) (Result, error) {
+	duration, err := probe.Duration()
+	if err != nil {
+		return Result{}, err
+	}
+	if duration <= 0 {
+		return Result{}, fmt.Errorf("zero duration")
+	}
+	stream := probe.VideoStream()
+	if stream == nil {
+		return Result{}, fmt.Errorf("no video stream")
+	}
+	var otherBits float64
+	for i := range probe.Streams {
+		if s := &probe.Streams[i]; s.CodecType == "audio" || s.CodecType == "subtitle" {
+			otherBits += s.Bits()
+		}
+	}
+	videoBytes := float64(size) - otherBits*duration/8
+	if videoBytes <= 0 {
+		// The container's sizes don't add up, fall back to the whole file
+		videoBytes = float64(size)
+	}
+	fps := stream.FrameRate()
+	if fps <= 0 {
+		fps = defaultFrameRate
+	}
+	targetBytes := targets.bits(stream.CodecName, stream.Width, stream.Height, fps) * duration / 8
+	result := Result{
+		Score:    TheoreticalBestScore * videoBytes / targetBytes,
+		Codec:    stream.CodecName,
+		Width:    stream.Width,
+		Height:   stream.Height,
+		Duration: duration,
+		BitRate:  videoBytes * 8 / duration,
+	}
+	if videoBytes > targetBytes {
+		result.CouldRecover = int64(videoBytes - targetBytes)
+	}
+	return result, nil
+}
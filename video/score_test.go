@@ -0,0 +1,102 @@
+package video_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamackay/all/video"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		file    string
+		size    uint64
+		targets video.Targets
+		want    video.Result
+		err     string
+	}{
+		{
+			// 16 Mbit/s of h264 at 1080p30 against an 8 Mbit/s target, with the 128 kbit/s of audio taken out
+			file: "h264_1080p.mp4",
+			size: 201600000,
+			want: video.Result{Score: 20, CouldRecover: 100000000, Codec: "h264", Width: 1920, Height: 1080,
+				Duration: 100, BitRate: 16000000},
+		},
+		{
+			file:    "h264_1080p.mp4",
+			size:    201600000,
+			targets: video.NewTargets(map[string]float64{"H264": 16}),
+			want: video.Result{Score: 10, Codec: "h264", Width: 1920, Height: 1080, Duration: 100,
+				BitRate: 16000000},
+		},
+		{
+			// The cover art is skipped for the 4K hevc stream, the BPS tags of the audio and subtitles come off
+			file: "cover_art.mkv",
+			size: 10000000000,
+			want: video.Result{Score: 11.085085585648146, CouldRecover: 936577368, Codec: "hevc", Width: 3840,
+				Height: 2160, Duration: 5400, BitRate: 14174734.814814815},
+		},
+		{file: "malformed.mp4", size: 1000, err: "unexpected end of JSON input"},
+		{file: "no_streams.mp4", size: 1000, err: "no video stream"},
+		{file: "audio_only.mp3", size: 9600000, err: "no video stream"},
+		{file: "missing.mp4", size: 1000, err: "Invalid data found when processing input"},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			targets := test.targets
+			if targets == nil {
+				targets = video.DefaultTargets
+			}
+			cache := video.NewCache(newFixtureProber())
+			probe, err := cache.Probe(test.file, test.size, time.Time{})
+			var result video.Result
+			if err == nil {
+				result, err = video.Score(probe, test.size, targets)
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(result.Score, test.want.Score) || !near(result.BitRate, test.want.BitRate) ||
+				!near(result.Duration, test.want.Duration) {
+				t.Errorf("got score %f, bit rate %f and duration %f, want %f, %f and %f", result.Score,
+					result.BitRate, result.Duration, test.want.Score, test.want.BitRate, test.want.Duration)
+			}
+			if result.CouldRecover != test.want.CouldRecover {
+				t.Errorf("could recover %d bytes, want %d", result.CouldRecover, test.want.CouldRecover)
+			}
+			if result.Codec != test.want.Codec || result.Width != test.want.Width || result.Height != test.want.Height {
+				t.Errorf("got %s %dx%d, want %s %dx%d", result.Codec, result.Width, result.Height,
+					test.want.Codec, test.want.Width, test.want.Height)
+			}
+		})
+	}
+}
+
+func TestVideoStreamSkipsCoverArt(t *testing.T) {
+	probe, err := newFixtureProber().Probe("cover_art.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := probe.VideoStream()
+	if stream == nil || stream.Index != 1 {
+		t.Fatalf("got stream %+v, want the hevc stream at index 1", stream)
+	}
+	if fps := stream.FrameRate(); !near(fps, 23.976023976) {
+		t.Errorf("frame rate is %f, want 23.976", fps)
+	}
+	if audio := probe.AudioStream(); audio == nil || audio.Bits() != 640000 {
+		t.Errorf("audio stream %+v should have 640000 bits/s from its BPS tag", audio)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b))
+}
@@ -0,0 +1,137 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// DefaultPreset is used when neither --preset nor the config pick one
+const DefaultPreset = "hevc"
+
+// DefaultPresets are the ffmpeg arguments placed between the input and output files, every stream is kept
+// and only the video is re-encoded
+var DefaultPresets = map[string]string{
+	"hevc": "-map 0 -c copy -c:v libx265 -crf 26 -preset medium -tag:v hvc1",
+	"h264": "-map 0 -c copy -c:v libx264 -crf 22 -preset slow",
+	"av1":  "-map 0 -c copy -c:v libsvtav1 -crf 32 -preset 6",
+}
+
+// PresetArgs looks up a preset by name in the config's presets and then the built in ones
+func PresetArgs(name string, config map[string]string) ([]string, error) {
+	if args, ok := config[name]; ok {
+		return strings.Fields(args), nil
+	}
+	if args, ok := DefaultPresets[name]; ok {
+		return strings.Fields(args), nil
+	}
+	return nil, fmt.Errorf("unknown preset %q", name)
+}
+
+type TranscodeJob struct {
+	Path string
+	Size uint64
+	// Length of the original in seconds, the output has to match it
+	Duration float64
+}
+
+type TranscodeResult struct {
+	Job      TranscodeJob
+	NewSize  uint64
+	Replaced bool
+	Err      error
+}
+
+// TranscodeAll runs the jobs in order with at most concurrency ffmpeg processes at once, done is called as each finishes
+func TranscodeAll(jobs []TranscodeJob, args []string, concurrency int, prober Prober, done func(TranscodeResult)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx := context.Background()
+	sem := semaphore.NewWeighted(int64(concurrency))
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, job := range jobs {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func(job TranscodeJob) {
+			defer wg.Done()
+			defer sem.Release(1)
+			result := Transcode(job, args, prober)
+			mutex.Lock()
+			defer mutex.Unlock()
+			done(result)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// Transcode re-encodes the file next to itself, then swaps it in for the original only if it's as long and smaller.
+// The rename is atomic so the original is never left half written. prober checks the length of the output
+func Transcode(job TranscodeJob, args []string, prober Prober) TranscodeResult {
+	result := TranscodeResult{Job: job}
+	info, err := os.Stat(job.Path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	// Same folder so the rename can't cross file systems, same extension so ffmpeg picks the same container
+	tmp := filepath.Join(filepath.Dir(job.Path), ".transcode-"+filepath.Base(job.Path))
+	defer os.Remove(tmp)
+
+	cmdArgs := append([]string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y", "-i", job.Path}, args...)
+	cmd := exec.Command("ffmpeg", append(cmdArgs, tmp)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		result.Err = fmt.Errorf("ffmpeg %s: %w: %s", job.Path, err, strings.TrimSpace(string(out)))
+		return result
+	}
+
+	probe, err := prober.Probe(tmp)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	duration, err := probe.Duration()
+	if err != nil {
+		result.Err = fmt.Errorf("transcoded %s: %w", job.Path, err)
+		return result
+	}
+	// Containers round differently, allow a second or 1% whichever is more
+	if tolerance := math.Max(1, job.Duration/100); math.Abs(duration-job.Duration) > tolerance {
+		result.Err = fmt.Errorf("transcoded %s is %.1fs long instead of %.1fs", job.Path, duration, job.Duration)
+		return result
+	}
+	tmpInfo, err := os.Stat(tmp)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.NewSize = uint64(tmpInfo.Size())
+	if result.NewSize >= job.Size {
+		return result
+	}
+
+	if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := os.Rename(tmp, job.Path); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Replaced = true
+	return result
+}